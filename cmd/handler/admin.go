@@ -8,8 +8,10 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth"
@@ -17,19 +19,37 @@ import (
 )
 
 type AdminHandler struct {
-	repo repository.AdminRepo
+	repo   repository.AdminRepo
+	stores *store.Registry
+	stats  map[string]func() any
+	routes []func(r chi.Router)
 }
 
 var tokenAhuth = jwtauth.New("HS256", []byte("secret"), nil)
 
 const cookieToken = "jwt"
 
-func NewAdminHandler(repo repository.AdminRepo) *AdminHandler {
+// NewAdminHandler inicializa el handler; stores valida la tienda del contenido (nil sin tiendas).
+func NewAdminHandler(repo repository.AdminRepo, stores *store.Registry) *AdminHandler {
 
 	return &AdminHandler{
-		repo: repo,
+		repo:   repo,
+		stores: stores,
+		stats:  make(map[string]func() any),
 	}
 }
+
+// AddStats registra una fuente de estadísticas que se muestra en GET /admin/stats bajo name
+// y sola en GET /admin/stats/{name}.
+func (h *AdminHandler) AddStats(name string, source func() any) {
+	h.stats[name] = source
+}
+
+// AddRoutes registra rutas de otros handlers bajo /admin, detrás de la sesión del admin.
+// Se llama antes de RegisterRoutes.
+func (h *AdminHandler) AddRoutes(routes func(r chi.Router)) {
+	h.routes = append(h.routes, routes)
+}
 func (h *AdminHandler) RegisterRoutes(r chi.Router) {
 
 	r.Post("/login", h.login)
@@ -42,11 +62,16 @@ func (h *AdminHandler) RegisterRoutes(r chi.Router) {
 		r.Get("/logout", h.Logout)
 		r.Get("/info", h.getInfo)
 		r.Get("/content", h.getContent)
+		r.Get("/stats", h.getStats)
+		r.Get("/stats/{name}", h.getStatsByName)
 		r.Post("/change-password", h.changePassword)
 		r.Post("/save-img/{id}", h.saveImg)
 		r.Post("/create-img/{id}", h.createImg)
 		r.Post("/save-content/{id}", h.saveContent)
 		r.Post("/create-content", h.createContent)
+		for _, routes := range h.routes {
+			routes(r)
+		}
 
 	})
 
@@ -63,9 +88,9 @@ func (h *AdminHandler) login(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, map[string]string{"error": "Invalid request body"})
 		return
 	}
-	username, err := h.repo.Authenticate(req.Usename, req.Password)
+	username, err := h.repo.Authenticate(r.Context(), req.Usename, req.Password)
 	if err != nil {
-		render.Status(r, http.StatusUnauthorized)
+		render.Status(r, errorStatus(err, http.StatusUnauthorized))
 		render.JSON(w, r, map[string]string{"error": err.Error()})
 		return
 	}
@@ -111,13 +136,13 @@ func (h *AdminHandler) changePassword(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, map[string]string{"error": "Invalid request body"})
 		return
 	}
-	if err := h.repo.ChangePassword(req.ID, req.OldPassword, req.NewPassword); err != nil {
+	if err := h.repo.ChangePassword(r.Context(), req.ID, req.OldPassword, req.NewPassword); err != nil {
 		if err.Error() == "user not found" || err.Error() == "old password is incorrect" {
 			render.Status(r, http.StatusBadRequest)
 			render.JSON(w, r, map[string]string{"error": err.Error()})
 			return
 		}
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "Failed to change password"})
 
 		return
@@ -156,7 +181,7 @@ func (h *AdminHandler) verifySession(w http.ResponseWriter, r *http.Request) {
 func (h *AdminHandler) serveImg(w http.ResponseWriter, r *http.Request) {
 	imgstr := chi.URLParam(r, "img")
 
-	path, err := h.repo.FindImg(imgstr)
+	path, err := h.repo.FindImg(r.Context(), imgstr)
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, map[string]string{"error": "error en el código de la imagen"})
@@ -204,9 +229,9 @@ func (h *AdminHandler) saveImg(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pathOldImg, err := h.repo.SaveImg(id, dstPath)
+	pathOldImg, err := h.repo.SaveImg(r.Context(), id, dstPath)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "error saving file"})
 		log.Println(err)
 		return
@@ -275,9 +300,9 @@ func (h *AdminHandler) createImg(w http.ResponseWriter, r *http.Request) {
 		log.Println(err)
 		return
 	}
-	id, err := h.repo.CreateImg(idContent, dstPath, datos.Name)
+	id, err := h.repo.CreateImg(r.Context(), idContent, dstPath, datos.Name)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "error saving file"})
 		log.Println(err)
 		return
@@ -292,6 +317,8 @@ func (h *AdminHandler) createContent(w http.ResponseWriter, r *http.Request) {
 		Title    string `json:"title"`
 		Contnet  string `json:"content"`
 		Location string `json:"location"`
+		// Store es el código de la tienda del contenido; vacío lo comparte entre todas.
+		Store string `json:"store"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
 		render.Status(r, http.StatusBadRequest)
@@ -299,9 +326,24 @@ func (h *AdminHandler) createContent(w http.ResponseWriter, r *http.Request) {
 		log.Println(err)
 		return
 	}
-	id, err := h.repo.CreateContent(data.Title, data.Contnet, data.Location)
+	code := ""
+	if strings.TrimSpace(data.Store) != "" {
+		if h.stores == nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, map[string]string{"error": "unknown store"})
+			return
+		}
+		s, ok := h.stores.Lookup(data.Store)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, map[string]string{"error": "unknown store"})
+			return
+		}
+		code = s.Code
+	}
+	id, err := h.repo.CreateContent(r.Context(), data.Title, data.Contnet, data.Location, code)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "error saving content"})
 		log.Println(err)
 		return
@@ -334,8 +376,8 @@ func (h *AdminHandler) saveContent(w http.ResponseWriter, r *http.Request) {
 		log.Println(err)
 		return
 	}
-	if err := h.repo.SaveContent(idContent, data.Title, data.Contnet); err != nil {
-		render.Status(r, http.StatusInternalServerError)
+	if err := h.repo.SaveContent(r.Context(), idContent, data.Title, data.Contnet); err != nil {
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "error saving content"})
 		log.Println(err)
 		return
@@ -347,16 +389,16 @@ func (h *AdminHandler) saveContent(w http.ResponseWriter, r *http.Request) {
 func (h *AdminHandler) getData(w http.ResponseWriter, r *http.Request) {
 	response := make(map[string]interface{})
 
-	info, err := h.repo.GetAllinfo()
+	info, err := h.repo.GetAllinfo(r.Context())
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "error geting info"})
 		log.Println(err)
 		return
 	}
-	content, err := h.repo.GetAllcontent()
+	content, err := h.repo.GetAllcontent(r.Context())
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "error geting content"})
 		log.Println(err)
 		return
@@ -374,9 +416,9 @@ func (h *AdminHandler) getData(w http.ResponseWriter, r *http.Request) {
 }
 func (h *AdminHandler) getInfo(w http.ResponseWriter, r *http.Request) {
 
-	info, err := h.repo.GetAllinfo()
+	info, err := h.repo.GetAllinfo(r.Context())
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "error geting info"})
 		log.Println(err)
 		return
@@ -388,9 +430,9 @@ func (h *AdminHandler) getInfo(w http.ResponseWriter, r *http.Request) {
 }
 func (h *AdminHandler) getContent(w http.ResponseWriter, r *http.Request) {
 
-	content, err := h.repo.GetAllcontent()
+	content, err := h.repo.GetAllcontent(r.Context())
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "error geting content"})
 		log.Println(err)
 		return
@@ -400,3 +442,22 @@ func (h *AdminHandler) getContent(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, content)
 
 }
+func (h *AdminHandler) getStats(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]any, len(h.stats))
+	for name, source := range h.stats {
+		response[name] = source()
+	}
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response)
+}
+
+func (h *AdminHandler) getStatsByName(w http.ResponseWriter, r *http.Request) {
+	source, ok := h.stats[chi.URLParam(r, "name")]
+	if !ok {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, map[string]string{"error": "Stats not found"})
+		return
+	}
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, source())
+}
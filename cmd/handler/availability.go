@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/service"
+)
+
+// AvailabilityHandler expone la disponibilidad de los productos en las tiendas físicas.
+type AvailabilityHandler struct {
+	svc service.AvailabilityService
+}
+
+// NewAvailabilityHandler inicializa el handler con su servicio.
+func NewAvailabilityHandler(s service.AvailabilityService) *AvailabilityHandler {
+	return &AvailabilityHandler{svc: s}
+}
+
+// RegisterRoutes monta la ruta junto a las de /products. Se llama después de
+// ProductHandler.RegisterRoutes, que instala CORS y la tienda de la petición.
+func (h *AvailabilityHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/products/{id}/availability", h.getAvailability) // GET /products/{id}/availability
+}
+
+// --- GET /products/{id}/availability ---
+func (h *AvailabilityHandler) getAvailability(w http.ResponseWriter, r *http.Request) {
+	prodID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || prodID < 1 {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "id inválido"})
+		return
+	}
+
+	shops, err := h.svc.ByShop(r.Context(), prodID)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("error getting availability of product %d: %v", prodID, err)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
+		render.JSON(w, r, map[string]string{"error": "Ha ocurrido un error en el servidor"})
+		return
+	}
+	render.JSON(w, r, shops)
+}
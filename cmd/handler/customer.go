@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/middleware"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/service"
+)
+
+// customerCookie guarda la sesión del cliente; es independiente de la cookie jwt del admin
+// y se firma con otra clave, así que ninguna de las dos sirve para la otra.
+const customerCookie = "customer_session"
+
+// maxCustomerBody limita el tamaño del JSON de alta, login y perfil.
+const maxCustomerBody = 16 << 10
+
+// CustomerHandler expone el alta, el login y el perfil de los clientes de la tienda.
+type CustomerHandler struct {
+	svc        service.CustomerService
+	auth       *jwtauth.JWTAuth
+	sessionTTL time.Duration
+	rateLimit  int
+}
+
+// NewCustomerHandler inicializa el handler; secret firma las sesiones de cliente y rateLimit
+// es el número de peticiones por minuto y por IP que aceptan las rutas de /account.
+func NewCustomerHandler(s service.CustomerService, secret string, sessionTTL time.Duration, rateLimit int) *CustomerHandler {
+	return &CustomerHandler{svc: s, auth: jwtauth.New("HS256", []byte(secret), nil), sessionTTL: sessionTTL, rateLimit: rateLimit}
+}
+
+// RegisterRoutes monta las rutas de cuentas. Se llama después de ProductHandler.RegisterRoutes,
+// que instala CORS y la tienda de la petición.
+func (h *CustomerHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/account", func(r chi.Router) {
+		// Limita los intentos de contraseña en el login y las altas en masa.
+		r.Use(middleware.RateLimit(h.rateLimit, time.Minute))
+		r.Post("/signup", h.signup)            // POST /account/signup {"email", "password", "name", "phone", ...}
+		r.Post("/login", h.login)              // POST /account/login {"login": email o teléfono, "password"}
+		r.Post("/logout", h.logout)            // POST /account/logout
+		r.Post("/verify-email", h.verifyEmail) // POST /account/verify-email {"token"}
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(h.Session)
+		r.Get("/me", h.profile)                        // GET /me
+		r.Put("/me", h.updateProfile)                  // PUT /me {"name", "phone", "street", "street2", "city", "zip"}
+		r.Post("/me/verify-email", h.sendVerification) // POST /me/verify-email
+		r.Get("/me/orders", h.orders)                  // GET /me/orders?page=&page_size=
+		r.Get("/me/orders/{ref}", h.order)             // GET /me/orders/{ref} (la referencia con / va como %2F)
+		r.Get("/me/orders/{ref}/tracking", h.tracking) // GET /me/orders/{ref}/tracking
+	})
+}
+
+type customerIDKey struct{}
+
+// CustomerID devuelve la cuenta de la sesión que validó Session.
+func CustomerID(ctx context.Context) int64 {
+	id, _ := ctx.Value(customerIDKey{}).(int64)
+	return id
+}
+
+// Session exige una sesión de cliente válida (cookie o cabecera Authorization: Bearer)
+// y guarda el id de la cuenta en el context.
+func (h *CustomerHandler) Session(next http.Handler) http.Handler {
+	verify := jwtauth.Verify(h.auth, func(r *http.Request) string {
+		cookie, err := r.Cookie(customerCookie)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}, jwtauth.TokenFromHeader)
+
+	return verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _, err := jwtauth.FromContext(r.Context())
+		if err != nil || token == nil {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, map[string]string{"error": "Unauthorized"})
+			return
+		}
+		id, err := strconv.ParseInt(token.Subject(), 10, 64)
+		if err != nil || id <= 0 {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, map[string]string{"error": "Unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), customerIDKey{}, id)))
+	}))
+}
+
+// startSession firma la sesión de la cuenta y la deja en la cookie.
+func (h *CustomerHandler) startSession(w http.ResponseWriter, r *http.Request, id int64) error {
+	_, tokenString, err := h.auth.Encode(map[string]interface{}{"sub": strconv.FormatInt(id, 10), "exp": jwtauth.ExpireIn(h.sessionTTL)})
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     customerCookie,
+		Value:    tokenString,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+		Expires:  time.Now().Add(h.sessionTTL),
+	})
+	return nil
+}
+
+// customerError traduce los errores de cuentas a la respuesta HTTP.
+func customerError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrInvalidCustomer), errors.Is(err, repository.ErrInvalidToken):
+	case errors.Is(err, repository.ErrOrderNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, repository.ErrCustomerExists):
+		status = http.StatusConflict
+	case errors.Is(err, repository.ErrInvalidCredentials), errors.Is(err, repository.ErrCustomerNotFound):
+		status = http.StatusUnauthorized
+	case errors.Is(err, service.ErrEmailNotVerified):
+		status = http.StatusForbidden
+	default:
+		log.Printf("error in customer account: %v", err)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
+		render.JSON(w, r, map[string]string{"error": "error en la cuenta del cliente"})
+		return
+	}
+	render.Status(r, status)
+	render.JSON(w, r, map[string]string{"error": err.Error()})
+}
+
+func decodeCustomerBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCustomerBody)).Decode(v); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "JSON inválido"})
+		return false
+	}
+	return true
+}
+
+// --- POST /account/signup ---
+// Responde 201 con el perfil y deja iniciada la sesión; 409 si el email o el teléfono ya
+// tienen cuenta.
+func (h *CustomerHandler) signup(w http.ResponseWriter, r *http.Request) {
+	var signup model.CustomerSignup
+	if !decodeCustomerBody(w, r, &signup) {
+		return
+	}
+	profile, err := h.svc.Signup(r.Context(), signup)
+	if err != nil {
+		customerError(w, r, err)
+		return
+	}
+	if err := h.startSession(w, r, profile.ID); err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, map[string]string{"error": "Failed to generate token"})
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, profile)
+}
+
+// --- POST /account/login ---
+func (h *CustomerHandler) login(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Login    string `json:"login"`
+		Password string `json:"password"`
+	}
+	if !decodeCustomerBody(w, r, &req) {
+		return
+	}
+	customer, err := h.svc.Login(r.Context(), req.Login, req.Password)
+	if err != nil {
+		customerError(w, r, err)
+		return
+	}
+	if err := h.startSession(w, r, customer.ID); err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, map[string]string{"error": "Failed to generate token"})
+		return
+	}
+	render.JSON(w, r, customer)
+}
+
+// --- POST /account/logout ---
+func (h *CustomerHandler) logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     customerCookie,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   -1,
+	})
+	render.JSON(w, r, map[string]string{"message": "Logout successful"})
+}
+
+// --- POST /account/verify-email ---
+func (h *CustomerHandler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if !decodeCustomerBody(w, r, &req) {
+		return
+	}
+	if err := h.svc.VerifyEmail(r.Context(), req.Token); err != nil {
+		customerError(w, r, err)
+		return
+	}
+	render.JSON(w, r, map[string]string{"message": "email verificado"})
+}
+
+// --- POST /me/verify-email ---
+func (h *CustomerHandler) sendVerification(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.SendVerification(r.Context(), CustomerID(r.Context())); err != nil {
+		customerError(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, map[string]string{"message": "enlace de verificación enviado"})
+}
+
+// --- GET /me ---
+func (h *CustomerHandler) profile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.svc.Profile(r.Context(), CustomerID(r.Context()))
+	if err != nil {
+		customerError(w, r, err)
+		return
+	}
+	render.JSON(w, r, profile)
+}
+
+// --- PUT /me ---
+func (h *CustomerHandler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	var address model.CustomerAddress
+	if !decodeCustomerBody(w, r, &address) {
+		return
+	}
+	profile, err := h.svc.UpdateProfile(r.Context(), CustomerID(r.Context()), address)
+	if err != nil {
+		customerError(w, r, err)
+		return
+	}
+	render.JSON(w, r, profile)
+}
+
+// --- GET /me/orders?page=&page_size= ---
+// Responde 403 mientras el email de la cuenta no esté verificado, igual que las rutas de un pedido.
+func (h *CustomerHandler) orders(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	orders, err := h.svc.Orders(r.Context(), CustomerID(r.Context()), page, pageSize)
+	if err != nil {
+		customerError(w, r, err)
+		return
+	}
+	render.JSON(w, r, orders)
+}
+
+// --- GET /me/orders/{ref} ---
+// Las referencias del punto de venta llevan barra ("Tienda/0001") y llegan escapadas.
+func (h *CustomerHandler) order(w http.ResponseWriter, r *http.Request) {
+	ref, err := url.PathUnescape(chi.URLParam(r, "ref"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "referencia inválida"})
+		return
+	}
+	order, err := h.svc.Order(r.Context(), CustomerID(r.Context()), ref)
+	if err != nil {
+		customerError(w, r, err)
+		return
+	}
+	render.JSON(w, r, order)
+}
+
+// --- GET /me/orders/{ref}/tracking ---
+func (h *CustomerHandler) tracking(w http.ResponseWriter, r *http.Request) {
+	ref, err := url.PathUnescape(chi.URLParam(r, "ref"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "referencia inválida"})
+		return
+	}
+	tracking, err := h.svc.Tracking(r.Context(), CustomerID(r.Context()), ref)
+	if err != nil {
+		customerError(w, r, err)
+		return
+	}
+	render.JSON(w, r, tracking)
+}
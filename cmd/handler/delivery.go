@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/service"
+)
+
+// DeliveryHandler expone el catálogo de direcciones de Cuba, el presupuesto de envío y,
+// para el admin, las zonas de entrega.
+type DeliveryHandler struct {
+	svc service.DeliveryService
+}
+
+// NewDeliveryHandler inicializa el handler con su servicio.
+func NewDeliveryHandler(s service.DeliveryService) *DeliveryHandler {
+	return &DeliveryHandler{svc: s}
+}
+
+// RegisterRoutes monta las rutas públicas. Se llama después de ProductHandler.RegisterRoutes,
+// que instala CORS y la tienda de la petición.
+func (h *DeliveryHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/provinces", h.getProvinces)                          // GET /provinces
+	r.Get("/provinces/{id}/municipalities", h.getMunicipalities) // GET /provinces/{id}/municipalities
+	r.Post("/delivery/quote", h.quote)                           // POST /delivery/quote {"municipalityId", "lines": [...]}
+}
+
+// RegisterAdminRoutes monta la gestión de zonas; se pasa a AdminHandler.AddRoutes.
+func (h *DeliveryHandler) RegisterAdminRoutes(r chi.Router) {
+	r.Get("/delivery-zones", h.getZones)           // GET /admin/delivery-zones
+	r.Post("/delivery-zones", h.createZone)        // POST /admin/delivery-zones
+	r.Get("/delivery-zones/{id}", h.getZone)       // GET /admin/delivery-zones/{id}
+	r.Put("/delivery-zones/{id}", h.updateZone)    // PUT /admin/delivery-zones/{id}
+	r.Delete("/delivery-zones/{id}", h.deleteZone) // DELETE /admin/delivery-zones/{id}
+}
+
+// deliveryError traduce los errores de direcciones y zonas a la respuesta HTTP.
+func deliveryError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidDelivery), errors.Is(err, repository.ErrUnknownMunicipality):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrZoneNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrZoneConflict):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+	default:
+		log.Printf("error in delivery: %v", err)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
+		render.JSON(w, r, map[string]string{"error": "Ha ocurrido un error en el servidor"})
+	}
+}
+
+// pathID lee el parámetro {id} de la ruta; ok es false si ya se respondió 400.
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "id inválido"})
+		return 0, false
+	}
+	return id, true
+}
+
+// --- GET /provinces ---
+func (h *DeliveryHandler) getProvinces(w http.ResponseWriter, r *http.Request) {
+	provinces, err := h.svc.Provinces(r.Context())
+	if err != nil {
+		deliveryError(w, r, err)
+		return
+	}
+	render.JSON(w, r, provinces)
+}
+
+// --- GET /provinces/{id}/municipalities ---
+func (h *DeliveryHandler) getMunicipalities(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	municipalities, err := h.svc.Municipalities(r.Context(), id)
+	if err != nil {
+		deliveryError(w, r, err)
+		return
+	}
+	render.JSON(w, r, municipalities)
+}
+
+// --- POST /delivery/quote ---
+// Responde con available a false si no hay envío a domicilio al municipio.
+func (h *DeliveryHandler) quote(w http.ResponseWriter, r *http.Request) {
+	var req model.DeliveryQuoteRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOrderBody)).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "JSON inválido"})
+		return
+	}
+	quote, err := h.svc.Quote(r.Context(), req)
+	if err != nil {
+		deliveryError(w, r, err)
+		return
+	}
+	render.JSON(w, r, quote)
+}
+
+// --- GET /admin/delivery-zones ---
+func (h *DeliveryHandler) getZones(w http.ResponseWriter, r *http.Request) {
+	zones, err := h.svc.Zones(r.Context())
+	if err != nil {
+		deliveryError(w, r, err)
+		return
+	}
+	render.JSON(w, r, zones)
+}
+
+// --- GET /admin/delivery-zones/{id} ---
+func (h *DeliveryHandler) getZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	zone, err := h.svc.Zone(r.Context(), id)
+	if err != nil {
+		deliveryError(w, r, err)
+		return
+	}
+	render.JSON(w, r, zone)
+}
+
+// decodeZone lee la zona del cuerpo; si no trae "active" la zona queda activa.
+func decodeZone(w http.ResponseWriter, r *http.Request) (model.DeliveryZone, bool) {
+	zone := model.DeliveryZone{Active: true}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOrderBody)).Decode(&zone); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "invalid JSON"})
+		return zone, false
+	}
+	return zone, true
+}
+
+// --- POST /admin/delivery-zones ---
+func (h *DeliveryHandler) createZone(w http.ResponseWriter, r *http.Request) {
+	zone, ok := decodeZone(w, r)
+	if !ok {
+		return
+	}
+	created, err := h.svc.CreateZone(r.Context(), zone)
+	if err != nil {
+		deliveryError(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, created)
+}
+
+// --- PUT /admin/delivery-zones/{id} ---
+// Reemplaza la zona entera, también su lista de municipios.
+func (h *DeliveryHandler) updateZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	zone, ok := decodeZone(w, r)
+	if !ok {
+		return
+	}
+	zone.ID = id
+	updated, err := h.svc.UpdateZone(r.Context(), zone)
+	if err != nil {
+		deliveryError(w, r, err)
+		return
+	}
+	render.JSON(w, r, updated)
+}
+
+// --- DELETE /admin/delivery-zones/{id} ---
+func (h *DeliveryHandler) deleteZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteZone(r.Context(), id); err != nil {
+		deliveryError(w, r, err)
+		return
+	}
+	render.NoContent(w, r)
+}
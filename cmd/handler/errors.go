@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// errorStatus traduce los errores de base de datos a un código HTTP:
+// 504 si la consulta superó su tiempo límite, 503 si no hay conexión utilizable
+// y fallback para cualquier otro error.
+func errorStatus(err error, fallback int) int {
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.As(err, &pqErr) && pqErr.Code == "57014": // query_canceled (statement_timeout)
+		return http.StatusGatewayTimeout
+	case errors.As(err, &pqErr) && pqErr.Code.Class() == "08": // connection_exception
+		return http.StatusServiceUnavailable
+	case errors.As(err, &pqErr) && pqErr.Code == "53300": // too_many_connections
+		return http.StatusServiceUnavailable
+	case errors.Is(err, sql.ErrConnDone), errors.Is(err, driver.ErrBadConn):
+		return http.StatusServiceUnavailable
+	}
+	return fallback
+}
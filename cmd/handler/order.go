@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/service"
+)
+
+// maxOrderBody limita el tamaño del JSON de un pedido.
+const maxOrderBody = 64 << 10
+
+// OrderHandler expone el checkout de la tienda.
+type OrderHandler struct {
+	svc service.OrderService
+}
+
+// NewOrderHandler inicializa el handler con el servicio.
+func NewOrderHandler(s service.OrderService) *OrderHandler {
+	return &OrderHandler{svc: s}
+}
+
+// RegisterRoutes monta las rutas de pedidos. Se llama después de ProductHandler.RegisterRoutes,
+// que instala CORS y la tienda de la petición.
+func (h *OrderHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/orders", h.create) // POST /orders (Idempotency-Key: <clave>) {"lines": [...], "customer": {...}}
+}
+
+// --- POST /orders ---
+// Responde 201 con el pedido creado, 200 si la clave ya tenía el mismo pedido, 400 si la
+// petición es inválida, 409 con las líneas que no coinciden con el precio o el stock actuales
+// y 422 si la clave ya se usó con otro carrito o cliente.
+func (h *OrderHandler) create(w http.ResponseWriter, r *http.Request) {
+	var order model.OrderRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOrderBody)).Decode(&order); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "JSON inválido"})
+		return
+	}
+
+	result, created, err := h.svc.PlaceOrder(r.Context(), r.Header.Get("Idempotency-Key"), order)
+	var rejected *repository.OrderRejectedError
+	switch {
+	case errors.Is(err, service.ErrInvalidOrder):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, repository.ErrIdempotencyMismatch):
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	case errors.As(err, &rejected):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, map[string]any{"error": err.Error(), "problems": rejected.Problems})
+		return
+	case err != nil:
+		log.Printf("error creating order: %v", err)
+		render.Status(r, errorStatus(err, http.StatusBadGateway))
+		render.JSON(w, r, map[string]string{"error": "no se pudo crear el pedido"})
+		return
+	}
+	if created {
+		render.Status(r, http.StatusCreated)
+	}
+	render.JSON(w, r, result)
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -19,33 +20,48 @@ import (
 	"github.com/go-chi/render"
 
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/env"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/middleware"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/service"
 )
 
 // ProductHandler expone los endpoints HTTP relacionados con productos.
 type ProductHandler struct {
-	svc service.ProductService
+	svc    service.ProductService
+	stores *store.Registry
 }
 
-// NewProductHandler inicializa el handler con el servicio.
-func NewProductHandler(s service.ProductService) *ProductHandler {
-	return &ProductHandler{svc: s}
+// NewProductHandler inicializa el handler con el servicio. stores puede ser nil si el
+// backend sirve una sola tienda.
+func NewProductHandler(s service.ProductService, stores *store.Registry) *ProductHandler {
+	return &ProductHandler{svc: s, stores: stores}
 }
 
 // RegisterRoutes monta todas las rutas en el router pasado.
 func (h *ProductHandler) RegisterRoutes(r chi.Router, env *env.Env) {
 	r.Use(middleware.CORSmiddleware(env))
 	r.Use(middleware.RecoverPanic())
+	r.Use(middleware.StoreContext(h.stores))
 	r.Get("/jireh-assistant", h.jirehAssistant)
+	conditional := middleware.ConditionalGET()
+	compress := middleware.Compress(env.CompressLevel)
 	r.Route("/products", func(r chi.Router) {
-		r.Get("/", h.getAll)                     // GET /products?page=&page_size=
-		r.Get("/{id}", h.getByID)                // GET /products/{id}
-		r.Post("/filtered", h.getFiltered)       // GET /products/filtered?categ_id=&min_price=&max_price=&page=&page_size=
-		r.Post("/related", h.getRelated)         // GET /products/related?limit=
-		r.Get("/best-selling", h.getBestSelling) // GET /products/best-selling?limit=
-		r.Get("/{id}/variants", h.getVariants)   // GET /products/{id}/variants
-		r.Get("/categories", h.getCategorys)
+		r.With(compress).Post("/filtered", h.getFiltered) // POST /products/filtered?categ_id=&min_price=&max_price=&page=&page_size= {"categories": [...]}
+		r.With(compress).Post("/related", h.getRelated)   // POST /products/related?limit=
+
+		// Las lecturas GET llevan ETag y Last-Modified y responden 304 si no cambiaron.
+		// La compresión va dentro para que cada codificación tenga su propio ETag.
+		r.Group(func(r chi.Router) {
+			r.Use(conditional, compress)
+			r.Get("/", h.getAll)                     // GET /products?page=&page_size=
+			r.Get("/filtered", h.getFiltered)        // GET /products/filtered?category=&categ_id=&min_price=&max_price=&page=&page_size=
+			r.Get("/{id}", h.getByID)                // GET /products/{id}
+			r.Get("/best-selling", h.getBestSelling) // GET /products/best-selling?limit=
+			r.Get("/on-sale", h.getOnSale)           // GET /products/on-sale?order_value=&page=&page_size=
+			r.Get("/{id}/variants", h.getVariants)   // GET /products/{id}/variants
+			r.Get("/categories", h.getCategorys)
+			r.Get("/attributes", h.getAttributes) // GET /products/attributes
+		})
 	})
 
 }
@@ -234,9 +250,9 @@ func (h *ProductHandler) jirehAssistant(w http.ResponseWriter, r *http.Request)
 	}
 
 	if responseText == "yes" {
-		cats, err := h.svc.GetCategorys()
+		cats, err := h.svc.GetCategorys(r.Context())
 		if err != nil {
-			render.Status(r, http.StatusInternalServerError)
+			render.Status(r, errorStatus(err, http.StatusInternalServerError))
 			render.JSON(w, r, map[string]string{"error": "failed to load categories"})
 			return
 		}
@@ -267,9 +283,9 @@ func (h *ProductHandler) jirehAssistant(w http.ResponseWriter, r *http.Request)
 			return
 		}
 		category := strings.TrimSpace(response.Candidates[0].Content.Parts[0].Text)
-		products, err := h.svc.GetFiltered(1, 5, nil, nil, nil, []string{category}, "", "")
+		products, err := h.svc.GetFiltered(r.Context(), 1, 5, nil, nil, nil, []string{category}, "", "", nil)
 		if err != nil {
-			render.Status(r, http.StatusInternalServerError)
+			render.Status(r, errorStatus(err, http.StatusInternalServerError))
 			render.JSON(w, r, map[string]string{"error": "failed to load products"})
 			return
 		}
@@ -343,20 +359,42 @@ func (h *ProductHandler) getAll(w http.ResponseWriter, r *http.Request) {
 		pageSize = 20
 	}
 
-	products, err := h.svc.GetAll(page, pageSize)
+	products, err := h.svc.GetAll(r.Context(), page, pageSize)
 	if err != nil {
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": err.Error()})
 		return
 	}
 	// Serializa Producto a JSON puro (se pueden mapear campos si se desea)
-	render.JSON(w, r, products)
+	renderProducts(w, r, products)
 }
 
 type Categories struct {
 	Categories []string `json:"categories"`
 }
 
-// --- GET /products/filtered?categ_id=&min_price=&max_price=&page=&page_size= ---
+// parseAttributeFilters lee los filtros de atributo con la forma attr[Color]=Rojo.
+// Un mismo atributo puede repetirse para aceptar varios valores.
+func parseAttributeFilters(q url.Values) map[string][]string {
+	attributes := make(map[string][]string)
+	for key, values := range q {
+		if !strings.HasPrefix(key, "attr[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		name := strings.TrimSpace(key[len("attr[") : len(key)-1])
+		if name == "" {
+			continue
+		}
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				attributes[name] = append(attributes[name], value)
+			}
+		}
+	}
+	return attributes
+}
+
+// --- GET|POST /products/filtered?categ_id=&min_price=&max_price=&attr[Color]=&page=&page_size= ---
 func (h *ProductHandler) getFiltered(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
@@ -395,24 +433,28 @@ func (h *ProductHandler) getFiltered(w http.ResponseWriter, r *http.Request) {
 		pageSize = 20
 	}
 
+	// En GET las categorías llegan repetidas en la query (?category=a&category=b) para
+	// que la respuesta sea cacheable; en POST siguen llegando en el cuerpo.
 	var categories Categories
-	err := json.NewDecoder(r.Body).Decode(&categories)
-	if err != nil {
+	if r.Method == http.MethodGet {
+		categories.Categories = q["category"]
+	} else if err := json.NewDecoder(r.Body).Decode(&categories); err != nil {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, map[string]string{"error": "Error in body request"})
 		return
 	}
 
 	name := r.URL.Query().Get("name")
-	products, err := h.svc.GetFiltered(page, pageSize, categID, minPrice, maxPrice, categories.Categories, name, orderValue)
+	attributes := parseAttributeFilters(q)
+	products, err := h.svc.GetFiltered(r.Context(), page, pageSize, categID, minPrice, maxPrice, categories.Categories, name, orderValue, attributes)
 	if err != nil {
 		log.Printf("Error: %v", err)
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "Error in server"})
 		return
 	}
 
-	render.JSON(w, r, products)
+	renderProducts(w, r, products)
 }
 
 // --- GET /products/{id}/related?limit= ---
@@ -447,12 +489,13 @@ func (h *ProductHandler) getRelated(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, map[string]string{"error": "error en la solicitud"})
 		return
 	}
-	products, err := h.svc.GetRelated(body.Category, body.Name, offset, page_size)
+	products, err := h.svc.GetRelated(r.Context(), body.Category, body.Name, offset, page_size)
 	if err != nil {
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": err.Error()})
 		return
 	}
-	render.JSON(w, r, products)
+	renderProducts(w, r, products)
 }
 
 func (h *ProductHandler) getByID(w http.ResponseWriter, r *http.Request) {
@@ -464,8 +507,9 @@ func (h *ProductHandler) getByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.svc.GetByID(prodID)
+	product, err := h.svc.GetByID(r.Context(), prodID)
 	if err != nil {
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": err.Error()})
 		return
 	}
@@ -476,19 +520,54 @@ func (h *ProductHandler) getByID(w http.ResponseWriter, r *http.Request) {
 func (h *ProductHandler) getBestSelling(w http.ResponseWriter, r *http.Request) {
 	limit, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
 
-	products, err := h.svc.GetBestSelling(limit)
+	products, err := h.svc.GetBestSelling(r.Context(), limit)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": "Ha ocurrido un error en el servidor"})
 		log.Printf("error: %v", err)
 		return
 	}
 	render.JSON(w, r, products)
 }
+
+// --- GET /products/on-sale?order_value=&page=&page_size= ---
+func (h *ProductHandler) getOnSale(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	products, err := h.svc.GetOnSale(r.Context(), page, pageSize, q.Get("order_value"))
+	if err != nil {
+		log.Printf("Error: %v", err)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
+		render.JSON(w, r, map[string]string{"error": "Error in server"})
+		return
+	}
+	renderProducts(w, r, products)
+}
+
+// --- GET /products/attributes ---
+func (h *ProductHandler) getAttributes(w http.ResponseWriter, r *http.Request) {
+	attributes, err := h.svc.GetAttributes(r.Context())
+	if err != nil {
+		log.Printf("Error: %v", err)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
+		render.JSON(w, r, map[string]string{"error": "Error in server"})
+		return
+	}
+	render.JSON(w, r, attributes)
+}
+
 func (h *ProductHandler) getCategorys(w http.ResponseWriter, r *http.Request) {
-	categorys, err := h.svc.GetCategorys()
+	categorys, err := h.svc.GetCategorys(r.Context())
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"Error: ": err.Error()})
 		return
 	}
@@ -505,8 +584,9 @@ func (h *ProductHandler) getVariants(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	variants, err := h.svc.GetVariants(prodID)
+	variants, err := h.svc.GetVariants(r.Context(), prodID)
 	if err != nil {
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
 		render.JSON(w, r, map[string]string{"error": err.Error()})
 		return
 	}
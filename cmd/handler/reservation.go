@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/middleware"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/service"
+)
+
+// ReservationHandler expone las reservas para recoger y pagar en una tienda física.
+type ReservationHandler struct {
+	svc       service.ReservationService
+	rateLimit int
+}
+
+// NewReservationHandler inicializa el handler; rateLimit es el número de peticiones por
+// minuto y por IP que aceptan las rutas públicas de reservas.
+func NewReservationHandler(s service.ReservationService, rateLimit int) *ReservationHandler {
+	return &ReservationHandler{svc: s, rateLimit: rateLimit}
+}
+
+// RegisterRoutes monta las rutas públicas. Se llama después de ProductHandler.RegisterRoutes,
+// que instala CORS y la tienda de la petición.
+func (h *ReservationHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/shops", h.getShops) // GET /shops
+	r.Route("/reservations", func(r chi.Router) {
+		r.Use(middleware.RateLimit(h.rateLimit, time.Minute))
+		r.Post("/", h.reserve)        // POST /reservations {"shopId", "lines": [...], "customer": {...}}
+		r.Get("/{code}", h.get)       // GET /reservations/{code}
+		r.Delete("/{code}", h.cancel) // DELETE /reservations/{code}
+	})
+}
+
+// RegisterAdminRoutes monta las rutas de caja; se pasan a AdminHandler.AddRoutes para que
+// queden detrás de la sesión del admin.
+func (h *ReservationHandler) RegisterAdminRoutes(r chi.Router) {
+	r.Get("/reservations/{code}", h.adminGet)              // GET /admin/reservations/{code}
+	r.Post("/reservations/{code}/collect", h.adminCollect) // POST /admin/reservations/{code}/collect
+}
+
+// reservationError traduce los errores de reservas a la respuesta HTTP.
+func reservationError(w http.ResponseWriter, r *http.Request, err error) {
+	var rejected *repository.OrderRejectedError
+	switch {
+	case errors.Is(err, service.ErrInvalidReservation):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrReservationNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrReservationClosed):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+	case errors.As(err, &rejected):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, map[string]any{"error": "no hay stock suficiente en la tienda", "problems": rejected.Problems})
+	default:
+		log.Printf("error in reservation: %v", err)
+		render.Status(r, errorStatus(err, http.StatusInternalServerError))
+		render.JSON(w, r, map[string]string{"error": "error en la reserva"})
+	}
+}
+
+// --- GET /shops ---
+func (h *ReservationHandler) getShops(w http.ResponseWriter, r *http.Request) {
+	shops, err := h.svc.Shops(r.Context())
+	if err != nil {
+		reservationError(w, r, err)
+		return
+	}
+	render.JSON(w, r, shops)
+}
+
+// --- POST /reservations ---
+// Responde 201 con la reserva y su código de recogida, 400 si la petición es inválida y
+// 409 con los productos que no tienen stock libre en la tienda.
+func (h *ReservationHandler) reserve(w http.ResponseWriter, r *http.Request) {
+	var req model.ReservationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOrderBody)).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "JSON inválido"})
+		return
+	}
+	reservation, err := h.svc.Reserve(r.Context(), req)
+	if err != nil {
+		reservationError(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, reservation)
+}
+
+// --- GET /reservations/{code} ---
+func (h *ReservationHandler) get(w http.ResponseWriter, r *http.Request) {
+	reservation, err := h.svc.Get(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		reservationError(w, r, err)
+		return
+	}
+	render.JSON(w, r, reservation)
+}
+
+// --- DELETE /reservations/{code} ---
+func (h *ReservationHandler) cancel(w http.ResponseWriter, r *http.Request) {
+	reservation, err := h.svc.Cancel(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		reservationError(w, r, err)
+		return
+	}
+	render.JSON(w, r, reservation)
+}
+
+// adminReservation añade a la reserva los datos de contacto, que la ruta pública no muestra.
+type adminReservation struct {
+	*model.Reservation
+	Email string `json:"email"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// --- GET /admin/reservations/{code} ---
+func (h *ReservationHandler) adminGet(w http.ResponseWriter, r *http.Request) {
+	reservation, err := h.svc.Get(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		reservationError(w, r, err)
+		return
+	}
+	render.JSON(w, r, adminReservation{Reservation: reservation, Email: reservation.Email, Phone: reservation.Phone})
+}
+
+// --- POST /admin/reservations/{code}/collect ---
+func (h *ReservationHandler) adminCollect(w http.ResponseWriter, r *http.Request) {
+	reservation, err := h.svc.Collect(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		reservationError(w, r, err)
+		return
+	}
+	render.JSON(w, r, adminReservation{Reservation: reservation, Email: reservation.Email, Phone: reservation.Phone})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// renderProducts escribe el ProductsResult producto a producto en lugar de serializar
+// la página completa como hace render.JSON; las imágenes en base64 hacen que una página
+// pese megabytes y así solo un producto está en memoria a la vez. El JSON es el mismo.
+// En GET y HEAD pone antes el ETag de los datos para que ConditionalGET no retenga la
+// respuesta para calcularlo.
+func renderProducts(w http.ResponseWriter, r *http.Request, result *model.ProductsResult) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		etag, err := productsETag(result)
+		if err != nil {
+			log.Printf("error encoding products: %v", err)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, map[string]string{"error": "Error in server"})
+			return
+		}
+		w.Header().Set("ETag", etag)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	out := bufio.NewWriterSize(w, 32<<10)
+	out.WriteString(`{"products":[`)
+	for i := range result.Products {
+		data, err := json.Marshal(&result.Products[i])
+		if err != nil {
+			// Las cabeceras ya se enviaron: se corta la conexión para que el cliente no tome
+			// un JSON truncado con estado 200 por la página completa.
+			log.Printf("error encoding product %d: %v", result.Products[i].ID, err)
+			panic(http.ErrAbortHandler)
+		}
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if _, err := out.Write(data); err != nil {
+			return
+		}
+	}
+	out.WriteString(`],"total":`)
+	out.WriteString(strconv.FormatUint(uint64(result.Total), 10))
+	out.WriteString("}\n")
+	if err := out.Flush(); err != nil {
+		log.Printf("error writing products: %v", err)
+	}
+}
+
+// productsETag es el hash de los productos de la página y del total, serializados uno a
+// uno igual que en renderProducts para no tener la página entera en memoria.
+func productsETag(result *model.ProductsResult) (string, error) {
+	hash := sha256.New()
+	for i := range result.Products {
+		data, err := json.Marshal(&result.Products[i])
+		if err != nil {
+			return "", fmt.Errorf("error encoding product %d: %w", result.Products[i].ID, err)
+		}
+		hash.Write(data)
+		hash.Write([]byte{','})
+	}
+	hash.Write([]byte(strconv.FormatUint(uint64(result.Total), 10)))
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`, nil
+}
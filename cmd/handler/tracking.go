@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/middleware"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/service"
+)
+
+// TrackingHandler expone el seguimiento público de pedidos (sin cuenta).
+type TrackingHandler struct {
+	svc       service.TrackingService
+	rateLimit int
+}
+
+// NewTrackingHandler inicializa el handler; rateLimit es el número de consultas por minuto
+// que acepta cada IP, para que no se puedan probar referencias y emails a ciegas.
+func NewTrackingHandler(s service.TrackingService, rateLimit int) *TrackingHandler {
+	return &TrackingHandler{svc: s, rateLimit: rateLimit}
+}
+
+// RegisterRoutes monta las rutas de seguimiento. Se llama después de ProductHandler.RegisterRoutes,
+// que instala CORS y la tienda de la petición.
+func (h *TrackingHandler) RegisterRoutes(r chi.Router) {
+	r.With(middleware.RateLimit(h.rateLimit, time.Minute)).Post("/orders/track", h.track) // POST /orders/track {"reference", "email"}
+}
+
+// --- POST /orders/track ---
+// El email va en el cuerpo y no en la URL para que no quede en los logs.
+func (h *TrackingHandler) track(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reference string `json:"reference"`
+		Email     string `json:"email"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCustomerBody)).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": "JSON inválido"})
+		return
+	}
+	tracking, err := h.svc.Lookup(r.Context(), req.Reference, req.Email)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("error tracking order: %v", err)
+		render.Status(r, errorStatus(err, http.StatusBadGateway))
+		render.JSON(w, r, map[string]string{"error": "no se pudo consultar el pedido"})
+		return
+	}
+	render.JSON(w, r, tracking)
+}
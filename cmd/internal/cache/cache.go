@@ -0,0 +1,194 @@
+// Package cache implementa una caché en memoria con TTL por entrada, límite de
+// memoria con expulsión LRU y agrupación de llamadas concurrentes (single-flight).
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats resume el uso de la caché.
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Shared    uint64 `json:"shared"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+	MaxBytes  int64  `json:"maxBytes"`
+}
+
+type entry struct {
+	key     string
+	value   any
+	size    int64
+	expires time.Time
+}
+
+type call struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// Cache guarda valores por clave. Es segura para uso concurrente.
+type Cache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	used     int64
+	ll       *list.List
+	items    map[string]*list.Element
+	calls    map[string]*call
+
+	hits, misses, shared, evictions atomic.Uint64
+}
+
+// New crea una caché que no guarda más de maxBytes (tamaño estimado como JSON).
+func New(maxBytes int64) *Cache {
+	return &Cache{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		calls:    make(map[string]*call),
+	}
+}
+
+// Get devuelve el valor si existe y no ha expirado.
+func (c *Cache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+func (c *Cache) get(key string) (any, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// Set guarda el valor durante ttl. Los valores más grandes que el límite no se guardan.
+func (c *Cache) Set(key string, value any, ttl time.Duration) {
+	size := sizeOf(value)
+	if ttl <= 0 || size > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	c.items[key] = c.ll.PushFront(&entry{key: key, value: value, size: size, expires: time.Now().Add(ttl)})
+	c.used += size
+	for c.used > c.maxBytes {
+		c.remove(c.ll.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// Do devuelve el valor de la caché o, si no está, ejecuta fn y guarda su resultado durante ttl.
+// Las llamadas concurrentes con la misma clave esperan a una sola ejecución de fn.
+// fn recibe un contexto que no se cancela con el del llamador, para que la cancelación
+// de una petición no haga fallar a las demás que esperan el mismo resultado; cada
+// llamador deja de esperar cuando su propio contexto termina.
+func (c *Cache) Do(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (any, error)) (any, error) {
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return value, nil
+	}
+	cl, inFlight := c.calls[key]
+	if inFlight {
+		c.shared.Add(1)
+	} else {
+		c.misses.Add(1)
+		cl = &call{done: make(chan struct{})}
+		c.calls[key] = cl
+		go func() {
+			cl.value, cl.err = fn(context.WithoutCancel(ctx))
+			if cl.err == nil {
+				c.Set(key, cl.value, ttl)
+			}
+			c.mu.Lock()
+			delete(c.calls, key)
+			c.mu.Unlock()
+			close(cl.done)
+		}()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.value, cl.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Delete elimina la clave.
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// DeletePrefix elimina todas las claves que empiezan por prefix y devuelve cuántas eran.
+func (c *Cache) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(el)
+			n++
+		}
+	}
+	return n
+}
+
+// Stats devuelve los contadores actuales.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Shared:    c.shared.Load(),
+		Evictions: c.evictions.Load(),
+		Entries:   len(c.items),
+		Bytes:     c.used,
+		MaxBytes:  c.maxBytes,
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	c.ll.Remove(el)
+	delete(c.items, e.key)
+	c.used -= e.size
+}
+
+// sizeOf estima la memoria de un valor por el tamaño de su JSON, que es lo que
+// termina enviándose al cliente.
+func sizeOf(value any) int64 {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"time"
 )
 
 var (
@@ -19,6 +20,26 @@ type DBConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+	// StatementTimeout se envía como statement_timeout de Postgres; 0 usa el del servidor.
+	StatementTimeout time.Duration
+
+	// Límites del pool de conexiones; 0 deja el valor por defecto de database/sql.
+	// GetByID lee las imágenes en paralelo, así que una petición de detalle puede ocupar
+	// dos conexiones a la vez; los listados usan una.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// DSN devuelve la cadena de conexión de lib/pq para la configuración.
+func (config DBConfig) DSN() string {
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		config.Host, config.Port, config.User, config.Password, config.Name, config.SSLMode)
+	if config.StatementTimeout > 0 {
+		connStr += fmt.Sprintf(" statement_timeout=%d", config.StatementTimeout.Milliseconds())
+	}
+	return connStr
 }
 
 func GetConnectionOdoo(config DBConfig) *sql.DB {
@@ -30,15 +51,14 @@ func GetConnectionAdmin(config DBConfig) *sql.DB {
 
 func getConnection(config DBConfig, sqlv *sql.DB) *sql.DB {
 	if sqlv == nil {
-		connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-			config.Host, config.Port, config.User, config.Password, config.Name, config.SSLMode)
-
 		var err error
-		sqlv, err = sql.Open("postgres", connStr)
+		sqlv, err = sql.Open("postgres", config.DSN())
 		if err != nil {
 			log.Fatalf("Error connecting to the database: %v", err)
 		}
 
+		config.configurePool(sqlv)
+
 		if err = sqlv.Ping(); err != nil {
 			log.Fatalf("Error pinging the database: %v", err)
 		}
@@ -46,3 +66,19 @@ func getConnection(config DBConfig, sqlv *sql.DB) *sql.DB {
 	}
 	return sqlv
 }
+
+// configurePool aplica los límites del pool de la configuración.
+func (config DBConfig) configurePool(conn *sql.DB) {
+	if config.MaxOpenConns > 0 {
+		conn.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		conn.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		conn.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+}
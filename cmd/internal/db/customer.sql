@@ -0,0 +1,23 @@
+-- Cuentas de los clientes de la tienda, enlazadas a su contacto de Odoo (res_partner).
+-- Las credenciales viven en el backend; Odoo no sabe nada de ellas.
+CREATE TABLE IF NOT EXISTS customer_account(
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    phone VARCHAR(50),
+    password VARCHAR(100) NOT NULL,
+    partner_id INTEGER NOT NULL,
+    email_verified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS customer_account_email ON customer_account (lower(email));
+CREATE UNIQUE INDEX IF NOT EXISTS customer_account_phone ON customer_account (phone) WHERE phone IS NOT NULL;
+-- Las cuentas sin verificar que pasan del plazo del enlace las reemplaza un alta nueva.
+
+-- Tokens de un solo uso (verificación de email); se guarda el sha256, nunca el token.
+CREATE TABLE IF NOT EXISTS customer_token(
+    token_hash CHAR(64) PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES customer_account(id) ON DELETE CASCADE,
+    purpose VARCHAR(20) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS customer_token_account ON customer_token (account_id);
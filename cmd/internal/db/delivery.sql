@@ -0,0 +1,18 @@
+-- Zonas de entrega a domicilio que gestiona el admin: cada municipio de l10n_cu_address
+-- (res_country_state_municipality) pertenece como mucho a una zona, con su precio de envío
+-- y su plazo de entrega. Un municipio sin zona no tiene envío a domicilio.
+CREATE TABLE IF NOT EXISTS delivery_zone(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    fee NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (fee >= 0),
+    -- free_from es el subtotal del carrito a partir del cual el envío es gratis (NULL = nunca).
+    free_from NUMERIC(12, 2) CHECK (free_from >= 0),
+    lead_time_days INTEGER NOT NULL DEFAULT 1 CHECK (lead_time_days >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE IF NOT EXISTS delivery_zone_municipality(
+    municipality_id INTEGER PRIMARY KEY,
+    zone_id INTEGER NOT NULL REFERENCES delivery_zone(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS delivery_zone_municipality_zone ON delivery_zone_municipality (zone_id);
@@ -21,4 +21,7 @@ CREATE TABLE IF NOT EXISTS info(
     id SERIAL PRIMARY KEY,
     key VARCHAR(100) NOT NULL,
     value TEXT NOT NULL
-);
\ No newline at end of file
+);
+-- Contenido e información por tienda (X-Store / host); NULL se comparte entre todas.
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS store VARCHAR(50);
+ALTER TABLE info ADD COLUMN IF NOT EXISTS store VARCHAR(50);
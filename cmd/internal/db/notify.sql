@@ -0,0 +1,34 @@
+CREATE OR REPLACE FUNCTION catalog_notify_change() RETURNS trigger AS $$
+DECLARE
+    rec jsonb;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := to_jsonb(OLD);
+    ELSE
+        rec := to_jsonb(NEW);
+    END IF;
+    PERFORM pg_notify('catalog_changes', jsonb_build_object(
+        'table', TG_TABLE_NAME,
+        'id', rec->'id',
+        'product_id', rec->'product_id',
+        'product_tmpl_id', rec->'product_tmpl_id',
+        'categ_id', rec->'categ_id'
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS catalog_notify_stock_quant ON stock_quant;
+CREATE TRIGGER catalog_notify_stock_quant
+    AFTER INSERT OR UPDATE OF quantity, location_id OR DELETE ON stock_quant
+    FOR EACH ROW EXECUTE FUNCTION catalog_notify_change();
+
+DROP TRIGGER IF EXISTS catalog_notify_product_template ON product_template;
+CREATE TRIGGER catalog_notify_product_template
+    AFTER INSERT OR UPDATE OR DELETE ON product_template
+    FOR EACH ROW EXECUTE FUNCTION catalog_notify_change();
+
+DROP TRIGGER IF EXISTS catalog_notify_pricelist_item ON product_pricelist_item;
+CREATE TRIGGER catalog_notify_pricelist_item
+    AFTER INSERT OR UPDATE OR DELETE ON product_pricelist_item
+    FOR EACH ROW EXECUTE FUNCTION catalog_notify_change();
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// WatchPools revisa los pools cada interval y avisa en el log cuando, desde la revisión
+// anterior, más de threshold peticiones tuvieron que esperar por una conexión libre.
+// Es la señal de que MaxOpenConns se queda corto para la carga.
+func WatchPools(ctx context.Context, interval time.Duration, threshold int64, pools func() map[string]sql.DBStats) {
+	last := pools()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current := pools()
+		for name, stats := range current {
+			prev := last[name]
+			waits := stats.WaitCount - prev.WaitCount
+			if waits > threshold {
+				waited := stats.WaitDuration - prev.WaitDuration
+				log.Printf("pool %s: %d waits for a connection in the last %s (avg %s, in use %d/%d)",
+					name, waits, interval, waited/time.Duration(waits), stats.InUse, stats.MaxOpenConnections)
+			}
+		}
+		last = current
+	}
+}
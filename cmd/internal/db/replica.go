@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// Reader es la parte de *sql.DB que usan los repositorios de solo lectura.
+// La implementan *sql.DB y *Cluster.
+type Reader interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// replicaLagQuery devuelve si el servidor es una réplica y su retraso en segundos.
+// Una réplica que ya aplicó todo lo recibido no tiene retraso aunque el primario esté inactivo.
+const replicaLagQuery = `SELECT pg_is_in_recovery(),
+	COALESCE(CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END, 0);`
+
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+	lag     atomic.Int64 // milisegundos
+}
+
+// ReplicaStats es el estado de una réplica en la última comprobación.
+type ReplicaStats struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	LagMs   int64  `json:"lagMs"`
+}
+
+// Cluster reparte las lecturas entre las réplicas sanas (round-robin) y usa el primario
+// cuando no hay ninguna. Las escrituras deben ir siempre a Primary.
+type Cluster struct {
+	Primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+}
+
+// NewCluster abre una conexión por réplica con la configuración del primario y el host
+// de cada réplica ("host" o "host:puerto"). Una réplica con retraso mayor que maxLag
+// deja de recibir lecturas hasta que se ponga al día.
+func NewCluster(primary *sql.DB, config DBConfig, replicas []string, maxLag time.Duration) *Cluster {
+	c := &Cluster{Primary: primary, maxLag: maxLag}
+	for _, addr := range replicas {
+		replicaConfig := config
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			replicaConfig.Host, replicaConfig.Port = host, port
+		} else {
+			replicaConfig.Host = addr
+		}
+		conn, err := sql.Open("postgres", replicaConfig.DSN())
+		if err != nil {
+			log.Printf("error opening replica %s: %v", addr, err)
+			continue
+		}
+		replicaConfig.configurePool(conn)
+		c.replicas = append(c.replicas, &replica{name: addr, db: conn})
+	}
+	c.check(context.Background())
+	return c
+}
+
+// Reader devuelve una réplica sana o, si no hay, el primario.
+func (c *Cluster) Reader() *sql.DB {
+	if r := c.pick(); r != nil {
+		return r.db
+	}
+	return c.Primary
+}
+
+func (c *Cluster) pick() *replica {
+	n := len(c.replicas)
+	for i := 0; i < n; i++ {
+		r := c.replicas[int(c.next.Add(1)%uint64(n))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// QueryContext ejecuta la consulta en una réplica sana. Si la réplica falla por la
+// conexión, se marca caída hasta la siguiente comprobación y se reintenta en el primario.
+func (c *Cluster) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	r := c.pick()
+	if r == nil {
+		return c.Primary.QueryContext(ctx, query, args...)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil && ctx.Err() == nil && isConnError(err) {
+		r.healthy.Store(false)
+		log.Printf("replica %s failed, falling back to primary: %v", r.name, err)
+		return c.Primary.QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+// QueryRowContext es como QueryContext para una sola fila: el error de la consulta ya está
+// en Row.Err antes del Scan, así que un fallo de conexión de la réplica se reintenta en el
+// primario.
+func (c *Cluster) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	r := c.pick()
+	if r == nil {
+		return c.Primary.QueryRowContext(ctx, query, args...)
+	}
+	row := r.db.QueryRowContext(ctx, query, args...)
+	if err := row.Err(); err != nil && ctx.Err() == nil && isConnError(err) {
+		r.healthy.Store(false)
+		log.Printf("replica %s failed, falling back to primary: %v", r.name, err)
+		return c.Primary.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
+// Run comprueba la salud y el retraso de las réplicas cada interval hasta que ctx termina.
+func (c *Cluster) Run(ctx context.Context, interval time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.check(ctx)
+		}
+	}
+}
+
+func (c *Cluster) check(ctx context.Context) {
+	for _, r := range c.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		var (
+			inRecovery bool
+			lag        float64
+		)
+		err := r.db.QueryRowContext(checkCtx, replicaLagQuery).Scan(&inRecovery, &lag)
+		cancel()
+
+		lagDuration := time.Duration(lag * float64(time.Second))
+		healthy := err == nil && (c.maxLag <= 0 || lagDuration <= c.maxLag)
+		r.lag.Store(lagDuration.Milliseconds())
+		if was := r.healthy.Swap(healthy); was != healthy {
+			switch {
+			case err != nil:
+				log.Printf("replica %s is down: %v", r.name, err)
+			case !healthy:
+				log.Printf("replica %s is lagging %s, reads go elsewhere", r.name, lagDuration)
+			case !inRecovery:
+				log.Printf("replica %s is healthy but not in recovery; is it a primary?", r.name)
+			default:
+				log.Printf("replica %s is healthy", r.name)
+			}
+		}
+	}
+}
+
+// Stats devuelve el estado de cada réplica.
+func (c *Cluster) Stats() []ReplicaStats {
+	stats := make([]ReplicaStats, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		stats = append(stats, ReplicaStats{
+			Name:    r.name,
+			Healthy: r.healthy.Load(),
+			LagMs:   r.lag.Load(),
+		})
+	}
+	return stats
+}
+
+// Pools devuelve las estadísticas del pool del primario y de cada réplica.
+func (c *Cluster) Pools() map[string]sql.DBStats {
+	pools := map[string]sql.DBStats{"primary": c.Primary.Stats()}
+	for _, r := range c.replicas {
+		pools["replica "+r.name] = r.db.Stats()
+	}
+	return pools
+}
+
+// Close cierra las conexiones de las réplicas; el primario lo cierra quien lo abrió.
+func (c *Cluster) Close() {
+	for _, r := range c.replicas {
+		r.db.Close()
+	}
+}
+
+// isConnError indica si el error viene de la conexión y no de la consulta.
+func isConnError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr)
+}
@@ -0,0 +1,24 @@
+-- Reservas para recoger en tienda: retienen stock de la ubicación de la tienda hasta que
+-- el cliente paga en el punto de venta o caducan. Mientras están activas y sin caducar
+-- se restan del stock del catálogo (ProductRepoConfig.Holds).
+CREATE TABLE IF NOT EXISTS stock_hold(
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(12) NOT NULL UNIQUE,
+    shop_id INTEGER NOT NULL,
+    location_id INTEGER NOT NULL,
+    customer_name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    phone VARCHAR(50),
+    state VARCHAR(20) NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS stock_hold_active ON stock_hold (location_id, expires_at) WHERE state = 'active';
+
+CREATE TABLE IF NOT EXISTS stock_hold_line(
+    id SERIAL PRIMARY KEY,
+    hold_id INTEGER NOT NULL REFERENCES stock_hold(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL,
+    quantity NUMERIC NOT NULL CHECK (quantity > 0)
+);
+CREATE INDEX IF NOT EXISTS stock_hold_line_hold ON stock_hold_line (hold_id);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS catalog_snapshot(
+    product_id INTEGER PRIMARY KEY,
+    product_tmpl_id INTEGER NOT NULL,
+    name JSONB NOT NULL,
+    name_search TEXT NOT NULL,
+    category_id INTEGER,
+    category_name VARCHAR(255),
+    list_price NUMERIC NOT NULL,
+    price NUMERIC NOT NULL,
+    sale_end TIMESTAMP,
+    stock NUMERIC NOT NULL,
+    image_ids INTEGER[] NOT NULL DEFAULT '{}',
+    refreshed_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS catalog_snapshot_stock_idx ON catalog_snapshot(stock) WHERE stock > 0;
+CREATE INDEX IF NOT EXISTS catalog_snapshot_category_idx ON catalog_snapshot(category_id);
+CREATE INDEX IF NOT EXISTS catalog_snapshot_price_idx ON catalog_snapshot(list_price);
@@ -1,8 +1,11 @@
 package env
 
 import (
+	"log"
 	"os"
+	"strconv"
 	"sync"
+	"time"
 
 	_ "github.com/lib/pq" // Importing pq for PostgreSQL driver
 )
@@ -17,6 +20,111 @@ type Env struct {
 	DBPassOdoo string
 	SSLMode    string
 	SecretKey  string
+	// Límites de cada pool de conexiones (primario y réplicas); 0 no limita.
+	DBMaxOpenConns    int64
+	DBMaxIdleConns    int64
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	// DBPoolWaitWarn avisa en el log cuando un pool acumula más esperas que este
+	// número en cada intervalo DBPoolWatchInterval.
+	DBPoolWaitWarn      int64
+	DBPoolWatchInterval time.Duration
+	// DBReplicas lista las réplicas de lectura ("host" o "host:puerto", separadas por comas),
+	// con las mismas credenciales que el primario. Vacío lee solo del primario.
+	DBReplicas string
+	// ReplicaMaxLag es el retraso máximo de una réplica para seguir recibiendo lecturas.
+	ReplicaMaxLag time.Duration
+	// ReplicaCheckInterval es cada cuánto se comprueban la salud y el retraso de las réplicas.
+	ReplicaCheckInterval time.Duration
+	// CatalogBackend elige de dónde lee el catálogo: "sql" (tablas de Odoo) o "rpc" (API JSON-RPC).
+	CatalogBackend string
+	// Conexión a la API JSON-RPC de Odoo; OdooPassword puede ser una API key.
+	OdooURL      string
+	OdooDB       string
+	OdooUser     string
+	OdooPassword string
+	OdooLang     string
+	// OrdersEnabled activa POST /orders, que crea presupuestos en Odoo por JSON-RPC
+	// (usa la conexión OdooURL aunque el catálogo sea "sql").
+	OrdersEnabled bool
+	// CustomersEnabled activa las cuentas de cliente (/account, /me), enlazadas a res.partner
+	// por JSON-RPC; las credenciales se guardan en cmd/internal/db/customer.sql.
+	CustomersEnabled bool
+	// POSOrders incluye los pedidos del punto de venta (pos.order) en el historial del cliente.
+	POSOrders bool
+	// CarrierTracking lee el transportista y su número de seguimiento de los albaranes
+	// (requiere el módulo delivery de Odoo).
+	CarrierTracking bool
+	// TrackingRateLimit es el número de consultas por minuto y por IP de POST /orders/track.
+	TrackingRateLimit int
+	// AccountRateLimit es el número de peticiones por minuto y por IP de /account (alta y login).
+	AccountRateLimit int
+	// ReservationsEnabled activa las reservas para recoger en tienda (/shops, /reservations).
+	ReservationsEnabled bool
+	// ReservationTTL es cuánto se guarda una reserva antes de liberar el stock.
+	ReservationTTL time.Duration
+	// ReservationSweepInterval es cada cuánto se marcan y notifican las reservas caducadas.
+	ReservationSweepInterval time.Duration
+	// ShopAvailability activa GET /products/{id}/availability (stock por tienda física).
+	ShopAvailability bool
+	// AvailabilityFewLeft es desde cuántas unidades el stock de una tienda deja de ser "few_left".
+	AvailabilityFewLeft float64
+	// ShopHoursFile es un JSON con los horarios de las tiendas ({"shop/3": "L-V 9:00-18:00"}).
+	ShopHoursFile string
+	// DeliveryZones activa el catálogo de provincias y municipios de l10n_cu_address, las zonas
+	// de entrega (/admin/delivery-zones) y POST /delivery/quote.
+	DeliveryZones bool
+	// TimeZone es la zona horaria de las tiendas (IANA), con la que se cuentan los días de entrega.
+	TimeZone string
+	// CustomerSecret firma las sesiones de cliente; debe ser distinta de la del admin.
+	CustomerSecret     string
+	CustomerSessionTTL time.Duration
+	// CustomerVerifyTTL es la validez del enlace de verificación del email; pasado ese plazo
+	// un alta nueva con el mismo email o teléfono reemplaza la cuenta sin verificar.
+	CustomerVerifyTTL time.Duration
+	// Servidor SMTP de los correos a clientes; SMTPAddr vacío los escribe en el log.
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPUser     string
+	SMTPPassword string
+	// OdooVersion fuerza la versión mayor de Odoo (15, 16 o 17); 0 la detecta al arrancar.
+	OdooVersion int
+	// CompressLevel es el nivel de gzip/brotli de las respuestas del catálogo.
+	CompressLevel int
+	// PricelistID es la tarifa de Odoo (product_pricelist) usada para calcular precios de venta.
+	PricelistID int64
+	// CatalogVisibility lista las banderas de Odoo que debe cumplir un producto para
+	// mostrarse: active, sale_ok, published.
+	CatalogVisibility string
+	// CompanyID limita el catálogo a una compañía de Odoo (res_company); 0 no filtra.
+	CompanyID int64
+	// StoresFile es un JSON con las tiendas (compañía, ubicación, tarifa, moneda y hosts) que
+	// se eligen por host o con la cabecera X-Store; vacío sirve una sola tienda.
+	StoresFile string
+	// QueryTimeout limita cada llamada a un repositorio desde el backend.
+	QueryTimeout time.Duration
+	// StatementTimeout es el statement_timeout de Postgres para las conexiones del backend.
+	StatementTimeout time.Duration
+	// CacheMaxBytes limita la memoria de la caché del catálogo; 0 la desactiva.
+	CacheMaxBytes int64
+	// TTL de la caché por tipo de consulta.
+	CacheTTLList        time.Duration
+	CacheTTLProduct     time.Duration
+	CacheTTLBestSelling time.Duration
+	CacheTTLOnSale      time.Duration
+	CacheTTLCategories  time.Duration
+	CacheTTLAttributes  time.Duration
+	// CacheNotify activa la invalidación de la caché con LISTEN/NOTIFY.
+	CacheNotify bool
+	// CacheNotifyTriggers instala los triggers de cmd/internal/db/notify.sql al arrancar
+	// (requiere permisos sobre las tablas de Odoo).
+	CacheNotifyTriggers bool
+
+	// CatalogSnapshot sirve los listados desde la tabla catalog_snapshot
+	// (cmd/internal/db/snapshot.sql), refrescada en segundo plano.
+	CatalogSnapshot             bool
+	CatalogSnapshotInterval     time.Duration
+	CatalogSnapshotFullInterval time.Duration
 }
 
 var (
@@ -36,6 +144,70 @@ func Start() *Env {
 			DBPassOdoo: getEnv("DB_PASS", "odoo"),
 			SSLMode:    getEnv("SSL_MODE", "disable"),
 			SecretKey:  getEnv("SECRET_KEY", "mysecretkey"),
+
+			DBMaxOpenConns:      getEnvInt("DB_MAX_OPEN_CONNS", 20),
+			DBMaxIdleConns:      getEnvInt("DB_MAX_IDLE_CONNS", 10),
+			DBConnMaxLifetime:   getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+			DBConnMaxIdleTime:   getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+			DBPoolWaitWarn:      getEnvInt("DB_POOL_WAIT_WARN", 10),
+			DBPoolWatchInterval: getEnvPositiveDuration("DB_POOL_WATCH_INTERVAL", time.Minute),
+
+			DBReplicas:           getEnv("DB_REPLICAS", ""),
+			ReplicaMaxLag:        getEnvDuration("REPLICA_MAX_LAG", 10*time.Second),
+			ReplicaCheckInterval: getEnvPositiveDuration("REPLICA_CHECK_INTERVAL", 5*time.Second),
+
+			CatalogBackend: getEnv("CATALOG_BACKEND", "sql"),
+			OdooURL:        getEnv("ODOO_URL", "http://localhost:8069"),
+			OdooDB:         getEnv("ODOO_DB", "odoo"),
+			OdooUser:       getEnv("ODOO_USER", "admin"),
+			OdooPassword:   getEnv("ODOO_PASSWORD", "admin"),
+			OdooLang:       getEnv("ODOO_LANG", "es_ES"),
+			OdooVersion:    int(getEnvInt("ODOO_VERSION", 0)),
+			OrdersEnabled:  getEnvBool("ORDERS_ENABLED", false),
+
+			CustomersEnabled:         getEnvBool("CUSTOMERS_ENABLED", false),
+			POSOrders:                getEnvBool("POS_ORDERS", true),
+			CarrierTracking:          getEnvBool("CARRIER_TRACKING", true),
+			TrackingRateLimit:        int(getEnvInt("TRACKING_RATE_LIMIT", 10)),
+			AccountRateLimit:         int(getEnvInt("ACCOUNT_RATE_LIMIT", 10)),
+			ReservationsEnabled:      getEnvBool("RESERVATIONS_ENABLED", false),
+			ReservationTTL:           getEnvPositiveDuration("RESERVATION_TTL", 24*time.Hour),
+			ReservationSweepInterval: getEnvPositiveDuration("RESERVATION_SWEEP_INTERVAL", time.Minute),
+			ShopAvailability:         getEnvBool("SHOP_AVAILABILITY", false),
+			AvailabilityFewLeft:      float64(getEnvInt("AVAILABILITY_FEW_LEFT", 5)),
+			ShopHoursFile:            getEnv("SHOP_HOURS_FILE", ""),
+			DeliveryZones:            getEnvBool("DELIVERY_ZONES", false),
+			TimeZone:                 getEnv("TIME_ZONE", "America/Havana"),
+			CustomerSecret:           getEnv("CUSTOMER_SECRET", "mycustomersecret"),
+			CustomerSessionTTL:       getEnvDuration("CUSTOMER_SESSION_TTL", 30*24*time.Hour),
+			CustomerVerifyTTL:        getEnvPositiveDuration("CUSTOMER_VERIFY_TTL", 48*time.Hour),
+			SMTPAddr:                 getEnv("SMTP_ADDR", ""),
+			SMTPFrom:                 getEnv("SMTP_FROM", "tienda@localhost"),
+			SMTPUser:                 getEnv("SMTP_USER", ""),
+			SMTPPassword:             getEnv("SMTP_PASSWORD", ""),
+
+			CompressLevel: int(getEnvInt("COMPRESS_LEVEL", 5)),
+
+			PricelistID:       getEnvInt("PRICELIST_ID", 1),
+			CatalogVisibility: getEnv("CATALOG_VISIBILITY", "active,sale_ok"),
+			CompanyID:         getEnvInt("COMPANY_ID", 0),
+			StoresFile:        getEnv("STORES_FILE", ""),
+			QueryTimeout:      getEnvDuration("QUERY_TIMEOUT", 5*time.Second),
+			StatementTimeout:  getEnvDuration("STATEMENT_TIMEOUT", 10*time.Second),
+
+			CacheMaxBytes:       getEnvInt("CACHE_MAX_BYTES", 64<<20),
+			CacheTTLList:        getEnvDuration("CACHE_TTL_LIST", time.Minute),
+			CacheTTLProduct:     getEnvDuration("CACHE_TTL_PRODUCT", 30*time.Second),
+			CacheTTLBestSelling: getEnvDuration("CACHE_TTL_BEST_SELLING", 5*time.Minute),
+			CacheTTLOnSale:      getEnvDuration("CACHE_TTL_ON_SALE", time.Minute),
+			CacheTTLCategories:  getEnvDuration("CACHE_TTL_CATEGORIES", 10*time.Minute),
+			CacheTTLAttributes:  getEnvDuration("CACHE_TTL_ATTRIBUTES", 10*time.Minute),
+			CacheNotify:         getEnvBool("CACHE_NOTIFY", false),
+			CacheNotifyTriggers: getEnvBool("CACHE_NOTIFY_TRIGGERS", false),
+
+			CatalogSnapshot:             getEnvBool("CATALOG_SNAPSHOT", false),
+			CatalogSnapshotInterval:     getEnvPositiveDuration("CATALOG_SNAPSHOT_INTERVAL", time.Minute),
+			CatalogSnapshotFullInterval: getEnvPositiveDuration("CATALOG_SNAPSHOT_FULL_INTERVAL", time.Hour),
 		}
 	})
 	return cfg
@@ -49,3 +221,53 @@ func getEnv(name string, fallback string) string {
 	return fallback
 
 }
+
+func getEnvInt(name string, fallback int64) int64 {
+	env, ok := os.LookupEnv(name)
+	if !ok {
+		return fallback
+	}
+	value, err := strconv.ParseInt(env, 10, 64)
+	if err != nil {
+		log.Printf("invalid value for %s: %v, using %d", name, err, fallback)
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(name string, fallback time.Duration) time.Duration {
+	env, ok := os.LookupEnv(name)
+	if !ok {
+		return fallback
+	}
+	value, err := time.ParseDuration(env)
+	if err != nil {
+		log.Printf("invalid value for %s: %v, using %s", name, err, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvPositiveDuration es getEnvDuration para intervalos que no pueden ser cero ni negativos
+// (p. ej. los de time.NewTicker).
+func getEnvPositiveDuration(name string, fallback time.Duration) time.Duration {
+	value := getEnvDuration(name, fallback)
+	if value <= 0 {
+		log.Printf("invalid value for %s: %s is not positive, using %s", name, value, fallback)
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(name string, fallback bool) bool {
+	env, ok := os.LookupEnv(name)
+	if !ok {
+		return fallback
+	}
+	value, err := strconv.ParseBool(env)
+	if err != nil {
+		log.Printf("invalid value for %s: %v, using %t", name, err, fallback)
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+// Package mailer envía los correos a los clientes de la tienda (verificación de email,
+// avisos). Sin servidor SMTP configurado los correos solo se escriben en el log.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// Mailer envía un correo de texto plano.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// Config es la conexión al servidor SMTP; Addr vacío usa el log.
+type Config struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// New devuelve el Mailer de la configuración.
+func New(config Config) Mailer {
+	if config.Addr == "" {
+		return logMailer{}
+	}
+	return &smtpMailer{config: config}
+}
+
+// logMailer escribe los correos en el log; sirve para desarrollo.
+type logMailer struct{}
+
+func (logMailer) Send(_ context.Context, to, subject, body string) error {
+	log.Printf("mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+type smtpMailer struct {
+	config Config
+}
+
+func (m *smtpMailer) Send(ctx context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid mail header")
+	}
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		host, _, err := net.SplitHostPort(m.config.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid smtp address: %w", err)
+		}
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, host)
+	}
+	msg := "From: " + m.config.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+
+	// net/smtp no acepta context; se envía en segundo plano y se deja de esperar al cancelarse.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.config.Addr, auth, m.config.From, []string{to}, []byte(msg))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("error sending mail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,93 @@
+// Package notify escucha los cambios del catálogo que publican los triggers de
+// cmd/internal/db/notify.sql mediante LISTEN/NOTIFY de Postgres.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel es el canal en el que publican los triggers.
+const Channel = "catalog_changes"
+
+// Event es el contenido de una notificación: la tabla modificada y los ids de la fila.
+type Event struct {
+	Table         string `json:"table"`
+	ID            int64  `json:"id"`
+	ProductID     int64  `json:"product_id"`
+	ProductTmplID int64  `json:"product_tmpl_id"`
+	CategID       int64  `json:"categ_id"`
+}
+
+// Listener mantiene la conexión LISTEN; lib/pq la reconecta sola cuando se cae.
+type Listener struct {
+	dsn         string
+	onEvent     func(Event)
+	onReconnect func()
+	up          atomic.Bool
+}
+
+// NewListener crea el listener. onEvent se llama por cada cambio; onReconnect se llama
+// al recuperar la conexión, porque las notificaciones enviadas mientras estaba caída se pierden.
+func NewListener(dsn string, onEvent func(Event), onReconnect func()) *Listener {
+	return &Listener{dsn: dsn, onEvent: onEvent, onReconnect: onReconnect}
+}
+
+// Up indica si la conexión LISTEN está activa. Mientras está caída la caché solo expira por TTL.
+func (l *Listener) Up() bool {
+	return l.up.Load()
+}
+
+// Run escucha hasta que ctx termina.
+func (l *Listener) Run(ctx context.Context) {
+	listener := pq.NewListener(l.dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventConnected:
+			l.up.Store(true)
+			log.Printf("catalog listener connected")
+		case pq.ListenerEventReconnected:
+			l.up.Store(true)
+			log.Printf("catalog listener reconnected")
+			if l.onReconnect != nil {
+				l.onReconnect()
+			}
+		case pq.ListenerEventDisconnected:
+			l.up.Store(false)
+			log.Printf("catalog listener disconnected, falling back to TTL: %v", err)
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Printf("catalog listener connection attempt failed: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(Channel); err != nil {
+		log.Printf("error listening on %s: %v", Channel, err)
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			// lib/pq envía nil tras reconectar.
+			if n == nil {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+				log.Printf("invalid catalog notification %q: %v", n.Extra, err)
+				continue
+			}
+			l.onEvent(event)
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
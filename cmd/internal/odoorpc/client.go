@@ -0,0 +1,294 @@
+// Package odoorpc es un cliente mínimo de la API externa JSON-RPC de Odoo (/jsonrpc),
+// con los métodos de ORM que usa el backend (search_read, search_count, read, read_group...).
+package odoorpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAuth indica que Odoo rechazó el usuario o la contraseña / API key.
+var ErrAuth = errors.New("odoo: authentication failed")
+
+// Config son los datos de conexión a Odoo. Password puede ser una API key del usuario.
+type Config struct {
+	URL      string
+	DB       string
+	Username string
+	Password string
+	// Lang es el idioma de los campos traducibles (context lang), p. ej. "es_ES".
+	Lang    string
+	Timeout time.Duration
+}
+
+// Error es un error devuelto por Odoo en la respuesta JSON-RPC.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Name    string `json:"name"`
+		Message string `json:"message"`
+	} `json:"data"`
+}
+
+func (e *Error) Error() string {
+	if e.Data.Message != "" {
+		return fmt.Sprintf("odoo: %s: %s", e.Data.Name, e.Data.Message)
+	}
+	return "odoo: " + e.Message
+}
+
+// Client llama a la API de Odoo. Es seguro para uso concurrente; el uid se obtiene
+// con el primer login y se reutiliza.
+type Client struct {
+	config Config
+	http   *http.Client
+	nextID atomic.Int64
+
+	mu  sync.Mutex
+	uid int64
+}
+
+// NewClient construye el cliente. No conecta hasta la primera llamada.
+func NewClient(config Config) *Client {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{config: config, http: &http.Client{Timeout: timeout}}
+}
+
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Service string `json:"service"`
+		Method  string `json:"method"`
+		Args    []any  `json:"args"`
+	} `json:"params"`
+	ID int64 `json:"id"`
+}
+
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// call hace una llamada JSON-RPC a service.method y decodifica el resultado en result.
+func (c *Client) call(ctx context.Context, service, method string, args []any, result any) error {
+	req := request{JSONRPC: "2.0", Method: "call", ID: c.nextID.Add(1)}
+	req.Params.Service, req.Params.Method, req.Params.Args = service, method, args
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("odoo: encoding request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.config.URL, "/")+"/jsonrpc", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("odoo: new request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("odoo: %s.%s: %w", service, method, err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("odoo: %s.%s: unexpected status %d", service, method, httpResp.StatusCode)
+	}
+
+	var resp response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("odoo: decoding response: %w", err)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("odoo: decoding %s.%s result: %w", service, method, err)
+	}
+	return nil
+}
+
+// Version devuelve la versión del servidor (server_version), p. ej. "16.0".
+func (c *Client) Version(ctx context.Context) (string, error) {
+	var version struct {
+		ServerVersion string `json:"server_version"`
+	}
+	if err := c.call(ctx, "common", "version", []any{}, &version); err != nil {
+		return "", err
+	}
+	return version.ServerVersion, nil
+}
+
+// login devuelve el uid del usuario, autenticándose la primera vez.
+func (c *Client) login(ctx context.Context) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.uid > 0 {
+		return c.uid, nil
+	}
+	// Odoo responde false si las credenciales no son válidas.
+	var uid json.RawMessage
+	if err := c.call(ctx, "common", "login", []any{c.config.DB, c.config.Username, c.config.Password}, &uid); err != nil {
+		return 0, err
+	}
+	var id int64
+	if err := json.Unmarshal(uid, &id); err != nil || id <= 0 {
+		return 0, ErrAuth
+	}
+	c.uid = id
+	return id, nil
+}
+
+// ExecuteKw llama a method del modelo con los argumentos posicionales y con nombre.
+// El idioma configurado se añade al context de kwargs.
+func (c *Client) ExecuteKw(ctx context.Context, model, method string, args []any, kwargs map[string]any, result any) error {
+	uid, err := c.login(ctx)
+	if err != nil {
+		return err
+	}
+	if kwargs == nil {
+		kwargs = map[string]any{}
+	}
+	if c.config.Lang != "" {
+		context, _ := kwargs["context"].(map[string]any)
+		merged := map[string]any{"lang": c.config.Lang}
+		for key, value := range context {
+			merged[key] = value
+		}
+		kwargs["context"] = merged
+	}
+	return c.call(ctx, "object", "execute_kw", []any{c.config.DB, uid, c.config.Password, model, method, args, kwargs}, result)
+}
+
+// Domain es un dominio de Odoo: condiciones [campo, operador, valor] y los operadores
+// prefijos "&", "|" y "!". Varias condiciones seguidas se combinan con AND.
+type Domain []any
+
+// Cond construye una condición del dominio.
+func Cond(field, operator string, value any) []any {
+	return []any{field, operator, value}
+}
+
+// Or combina las condiciones con OR en notación prefija.
+func Or(conds ...[]any) Domain {
+	var domain Domain
+	for i := 1; i < len(conds); i++ {
+		domain = append(domain, "|")
+	}
+	for _, cond := range conds {
+		domain = append(domain, cond)
+	}
+	return domain
+}
+
+// SearchOptions son los parámetros con nombre de search_read.
+type SearchOptions struct {
+	Fields  []string
+	Offset  int
+	Limit   int
+	Order   string
+	Context map[string]any
+}
+
+func (o SearchOptions) kwargs() map[string]any {
+	kwargs := map[string]any{}
+	if len(o.Fields) > 0 {
+		kwargs["fields"] = o.Fields
+	}
+	if o.Offset > 0 {
+		kwargs["offset"] = o.Offset
+	}
+	if o.Limit > 0 {
+		kwargs["limit"] = o.Limit
+	}
+	if o.Order != "" {
+		kwargs["order"] = o.Order
+	}
+	if o.Context != nil {
+		kwargs["context"] = o.Context
+	}
+	return kwargs
+}
+
+func domainArg(domain Domain) Domain {
+	if domain == nil {
+		return Domain{}
+	}
+	return domain
+}
+
+// SearchRead busca los registros del dominio y decodifica sus campos en result (un slice de structs).
+func (c *Client) SearchRead(ctx context.Context, model string, domain Domain, opts SearchOptions, result any) error {
+	return c.ExecuteKw(ctx, model, "search_read", []any{domainArg(domain)}, opts.kwargs(), result)
+}
+
+// SearchCount cuenta los registros del dominio.
+func (c *Client) SearchCount(ctx context.Context, model string, domain Domain, context map[string]any) (int, error) {
+	var count int
+	kwargs := map[string]any{}
+	if context != nil {
+		kwargs["context"] = context
+	}
+	err := c.ExecuteKw(ctx, model, "search_count", []any{domainArg(domain)}, kwargs, &count)
+	return count, err
+}
+
+// Read lee los campos de los ids dados en result (un slice de structs).
+func (c *Client) Read(ctx context.Context, model string, ids []int64, fields []string, context map[string]any, result any) error {
+	kwargs := map[string]any{"fields": fields}
+	if context != nil {
+		kwargs["context"] = context
+	}
+	return c.ExecuteKw(ctx, model, "read", []any{ids}, kwargs, result)
+}
+
+// ReadGroup agrupa los registros del dominio. fields admite agregados como "qty:sum".
+func (c *Client) ReadGroup(ctx context.Context, model string, domain Domain, fields, groupBy []string, opts SearchOptions, result any) error {
+	kwargs := opts.kwargs()
+	delete(kwargs, "order")
+	delete(kwargs, "fields")
+	if opts.Order != "" {
+		kwargs["orderby"] = opts.Order
+	}
+	kwargs["lazy"] = false
+	return c.ExecuteKw(ctx, model, "read_group", []any{domainArg(domain), fields, groupBy}, kwargs, result)
+}
+
+// Create crea un registro de model con values y devuelve su id. Los one2many admiten los
+// comandos de Odoo, p. ej. [][]any{{0, 0, línea}}.
+func (c *Client) Create(ctx context.Context, model string, values map[string]any, context map[string]any) (int64, error) {
+	kwargs := map[string]any{}
+	if context != nil {
+		kwargs["context"] = context
+	}
+	var id int64
+	err := c.ExecuteKw(ctx, model, "create", []any{values}, kwargs, &id)
+	return id, err
+}
+
+// Write actualiza los registros ids de model con values.
+func (c *Client) Write(ctx context.Context, model string, ids []int64, values map[string]any, context map[string]any) error {
+	kwargs := map[string]any{}
+	if context != nil {
+		kwargs["context"] = context
+	}
+	return c.ExecuteKw(ctx, model, "write", []any{ids, values}, kwargs, nil)
+}
+
+// Unlink borra los registros ids de model.
+func (c *Client) Unlink(ctx context.Context, model string, ids []int64) error {
+	return c.ExecuteKw(ctx, model, "unlink", []any{ids}, map[string]any{}, nil)
+}
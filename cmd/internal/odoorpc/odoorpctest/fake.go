@@ -0,0 +1,873 @@
+// Package odoorpctest tiene un servidor Odoo JSON-RPC falso en memoria y datos de prueba
+// para los tests de los repositorios que usan odoorpc. Solo lo importan los tests.
+package odoorpctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc"
+)
+
+// Record es un registro del servidor falso. Los many2one se guardan como el id (int64)
+// y los one2many/many2many como []int64.
+type Record map[string]any
+
+// FakeServer es un servidor Odoo JSON-RPC en memoria para probar sin un Odoo real.
+// Implementa common.version, common.login y, en object.execute_kw, search, search_read,
+// search_count, read, read_group, create y write sobre los registros cargados con Add.
+// No calcula campos: qty_available y similares se cargan ya calculados en cada registro,
+// salvo los que se registren con Compute.
+type FakeServer struct {
+	DB       string
+	Username string
+	Password string
+	Version  string
+
+	mu        sync.Mutex
+	records   map[string][]Record
+	relations map[string]map[string]string
+	inverses  map[string]map[string]string
+	computes  map[string][]ComputeFunc
+	server    *httptest.Server
+}
+
+// ComputeFunc recalcula campos de record; find busca un registro de cualquier modelo.
+type ComputeFunc func(record Record, find func(model string, id int64) Record)
+
+// NewFakeServer crea el servidor sin arrancarlo; las credenciales válidas son las dadas.
+func NewFakeServer(db, username, password string) *FakeServer {
+	return &FakeServer{
+		DB:        db,
+		Username:  username,
+		Password:  password,
+		Version:   "16.0",
+		records:   make(map[string][]Record),
+		relations: make(map[string]map[string]string),
+		inverses:  make(map[string]map[string]string),
+		computes:  make(map[string][]ComputeFunc),
+	}
+}
+
+// Start arranca el servidor en un puerto local y devuelve su URL.
+func (f *FakeServer) Start() string {
+	f.server = httptest.NewServer(f)
+	return f.server.URL
+}
+
+// Close detiene el servidor.
+func (f *FakeServer) Close() {
+	if f.server != nil {
+		f.server.Close()
+	}
+}
+
+// Config devuelve la configuración de cliente para conectarse al servidor arrancado.
+func (f *FakeServer) Config() odoorpc.Config {
+	return odoorpc.Config{URL: f.server.URL, DB: f.DB, Username: f.Username, Password: f.Password}
+}
+
+// Relate declara que field de model apunta a comodel (many2one, one2many o many2many).
+func (f *FakeServer) Relate(model, field, comodel string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.relations[model] == nil {
+		f.relations[model] = make(map[string]string)
+	}
+	f.relations[model][field] = comodel
+}
+
+// RelateMany declara un one2many de model hacia comodel cuyo many2one inverso es inverse.
+// En create y write acepta los comandos (0, 0, vals), (4, id), (5,) y (6, 0, ids).
+func (f *FakeServer) RelateMany(model, field, comodel, inverse string) {
+	f.Relate(model, field, comodel)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.inverses[model] == nil {
+		f.inverses[model] = make(map[string]string)
+	}
+	f.inverses[model][field] = inverse
+}
+
+// Compute registra fn para recalcular los registros de model después de cada create o
+// write, en lugar de los campos calculados de Odoo.
+func (f *FakeServer) Compute(model string, fn ComputeFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.computes[model] = append(f.computes[model], fn)
+}
+
+// Add carga registros en model. Cada uno debe tener "id".
+func (f *FakeServer) Add(model string, records ...Record) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, record := range records {
+		record["id"] = toInt(record["id"])
+		f.records[model] = append(f.records[model], record)
+	}
+}
+
+// Records devuelve una copia de los registros de model, para comprobar escrituras.
+func (f *FakeServer) Records(model string) []Record {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]Record, len(f.records[model]))
+	for i, record := range f.records[model] {
+		copied := Record{}
+		for key, value := range record {
+			copied[key] = value
+		}
+		out[i] = copied
+	}
+	return out
+}
+
+type fakeRequest struct {
+	ID     any `json:"id"`
+	Params struct {
+		Service string            `json:"service"`
+		Method  string            `json:"method"`
+		Args    []json.RawMessage `json:"args"`
+	} `json:"params"`
+}
+
+func (f *FakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/jsonrpc" {
+		http.NotFound(w, r)
+		return
+	}
+	var req fakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := f.dispatch(req.Params.Service, req.Params.Method, req.Params.Args)
+	resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	if err != nil {
+		rpcErr := &odoorpc.Error{Code: 200, Message: "Odoo Server Error"}
+		rpcErr.Data.Name = "odoo.exceptions.UserError"
+		rpcErr.Data.Message = err.Error()
+		resp["error"] = rpcErr
+	} else {
+		resp["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (f *FakeServer) dispatch(service, method string, args []json.RawMessage) (any, error) {
+	switch service + "." + method {
+	case "common.version":
+		return map[string]any{"server_version": f.Version}, nil
+	case "common.login", "common.authenticate":
+		var db, user, password string
+		if len(args) < 3 || json.Unmarshal(args[0], &db) != nil || json.Unmarshal(args[1], &user) != nil || json.Unmarshal(args[2], &password) != nil {
+			return nil, fmt.Errorf("invalid login arguments")
+		}
+		if db != f.DB || user != f.Username || password != f.Password {
+			return false, nil
+		}
+		return 2, nil
+	case "object.execute_kw":
+		return f.executeKw(args)
+	}
+	return nil, fmt.Errorf("service %s.%s not implemented by the fake server", service, method)
+}
+
+func (f *FakeServer) executeKw(raw []json.RawMessage) (any, error) {
+	if len(raw) < 6 {
+		return nil, fmt.Errorf("execute_kw expects at least 6 arguments")
+	}
+	var (
+		uid             int64
+		password, model string
+		method          string
+		args            []any
+		kwargs          map[string]any
+		decodeErr       error
+		decode          = func(i int, v any) {
+			if decodeErr == nil {
+				decodeErr = json.Unmarshal(raw[i], v)
+			}
+		}
+	)
+	decode(1, &uid)
+	decode(2, &password)
+	decode(3, &model)
+	decode(4, &method)
+	decode(5, &args)
+	if len(raw) > 6 {
+		decode(6, &kwargs)
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	if uid != 2 || password != f.Password {
+		return nil, fmt.Errorf("Access Denied")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	arg := func(i int, name string) any {
+		if i < len(args) {
+			return args[i]
+		}
+		return kwargs[name]
+	}
+	switch method {
+	case "search", "search_read", "search_count":
+		domain, _ := arg(0, "domain").([]any)
+		matched, err := f.search(model, domain)
+		if err != nil {
+			return nil, err
+		}
+		if method == "search_count" {
+			return len(matched), nil
+		}
+		order, _ := kwargs["order"].(string)
+		f.sortRecords(model, matched, order)
+		matched = window(matched, toInt(kwargs["offset"]), toInt(kwargs["limit"]))
+		if method == "search" {
+			ids := make([]int64, len(matched))
+			for i, record := range matched {
+				ids[i] = toInt(record["id"])
+			}
+			return ids, nil
+		}
+		return f.render(model, matched, toStrings(kwargs["fields"])), nil
+	case "read":
+		ids := toInts(arg(0, "ids"))
+		var matched []Record
+		for _, id := range ids {
+			if record := f.find(model, id); record != nil {
+				matched = append(matched, record)
+			}
+		}
+		return f.render(model, matched, toStrings(arg(1, "fields"))), nil
+	case "read_group":
+		domain, _ := arg(0, "domain").([]any)
+		matched, err := f.search(model, domain)
+		if err != nil {
+			return nil, err
+		}
+		order, _ := kwargs["orderby"].(string)
+		return f.readGroup(model, matched, toStrings(arg(1, "fields")), toStrings(arg(2, "groupby")), order, toInt(kwargs["offset"]), toInt(kwargs["limit"]))
+	case "create":
+		values, _ := arg(0, "vals").(map[string]any)
+		return f.create(model, values), nil
+	case "write":
+		values, _ := arg(1, "vals").(map[string]any)
+		for _, id := range toInts(arg(0, "ids")) {
+			record := f.find(model, id)
+			if record == nil {
+				return nil, fmt.Errorf("record %s(%d) does not exist", model, id)
+			}
+			f.assign(model, record, values)
+			f.compute(model, record)
+		}
+		return true, nil
+	case "unlink":
+		for _, id := range toInts(arg(0, "ids")) {
+			if f.find(model, id) == nil {
+				return nil, fmt.Errorf("record %s(%d) does not exist", model, id)
+			}
+			kept := f.records[model][:0]
+			for _, record := range f.records[model] {
+				if toInt(record["id"]) != id {
+					kept = append(kept, record)
+				}
+			}
+			f.records[model] = kept
+		}
+		return true, nil
+	}
+	return nil, fmt.Errorf("method %s not implemented by the fake server", method)
+}
+
+// create añade un registro con el siguiente id libre y devuelve el id.
+func (f *FakeServer) create(model string, values map[string]any) int64 {
+	id := int64(1)
+	for _, record := range f.records[model] {
+		if rid := toInt(record["id"]); rid >= id {
+			id = rid + 1
+		}
+	}
+	record := Record{"id": id}
+	f.records[model] = append(f.records[model], record)
+	f.assign(model, record, values)
+	f.compute(model, record)
+	return id
+}
+
+// compute aplica las funciones registradas con Compute.
+func (f *FakeServer) compute(model string, record Record) {
+	for _, fn := range f.computes[model] {
+		fn(record, f.find)
+	}
+}
+
+// assign copia values al registro con los tipos internos (ids como int64).
+// Los comandos x2many solo se interpretan en los campos declarados con RelateMany;
+// en el resto se guardan tal cual.
+func (f *FakeServer) assign(model string, record Record, values map[string]any) {
+	for key, value := range values {
+		if comodel, relational := f.relations[model][key]; relational {
+			switch v := value.(type) {
+			case float64:
+				record[key] = int64(v)
+				continue
+			case []any:
+				if ids := toInts(v); len(ids) == len(v) && isIDList(v) {
+					record[key] = ids
+					continue
+				}
+				if inverse, ok := f.inverses[model][key]; ok {
+					record[key] = f.commands(comodel, inverse, record, key, v)
+					continue
+				}
+			}
+		}
+		record[key] = value
+	}
+}
+
+// commands aplica los comandos x2many al campo field de parent y devuelve los ids resultantes.
+func (f *FakeServer) commands(comodel, inverse string, parent Record, field string, commands []any) []int64 {
+	ids := toInts(parent[field])
+	for _, raw := range commands {
+		command, _ := raw.([]any)
+		if len(command) == 0 {
+			continue
+		}
+		switch toInt(command[0]) {
+		case 0:
+			values := map[string]any{}
+			if len(command) > 2 {
+				if v, ok := command[2].(map[string]any); ok {
+					values = v
+				}
+			}
+			values[inverse] = parent["id"]
+			ids = append(ids, f.create(comodel, values))
+		case 4:
+			if len(command) > 1 {
+				ids = append(ids, toInt(command[1]))
+			}
+		case 5:
+			ids = nil
+		case 6:
+			if len(command) > 2 {
+				ids = toInts(command[2])
+			}
+		}
+	}
+	return ids
+}
+
+func isIDList(values []any) bool {
+	for _, value := range values {
+		if _, ok := value.(float64); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *FakeServer) find(model string, id int64) Record {
+	for _, record := range f.records[model] {
+		if toInt(record["id"]) == id {
+			return record
+		}
+	}
+	return nil
+}
+
+func (f *FakeServer) search(model string, domain []any) ([]Record, error) {
+	var matched []Record
+	for _, record := range f.records[model] {
+		// Como Odoo, los registros archivados se excluyen salvo que el dominio pregunte por active.
+		if active, ok := record["active"].(bool); ok && !active && !mentions(domain, "active") {
+			continue
+		}
+		ok, err := f.match(model, record, domain)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, record)
+		}
+	}
+	return matched, nil
+}
+
+func mentions(domain []any, field string) bool {
+	for _, term := range domain {
+		if leaf, ok := term.([]any); ok && len(leaf) == 3 && leaf[0] == field {
+			return true
+		}
+	}
+	return false
+}
+
+// match evalúa el dominio en notación prefija sobre el registro.
+func (f *FakeServer) match(model string, record Record, domain []any) (bool, error) {
+	var stack []bool
+	for i := len(domain) - 1; i >= 0; i-- {
+		switch term := domain[i].(type) {
+		case string:
+			switch term {
+			case "!":
+				if len(stack) < 1 {
+					return false, fmt.Errorf("invalid domain")
+				}
+				stack[len(stack)-1] = !stack[len(stack)-1]
+			case "&", "|":
+				if len(stack) < 2 {
+					return false, fmt.Errorf("invalid domain")
+				}
+				a, b := stack[len(stack)-1], stack[len(stack)-2]
+				stack = stack[:len(stack)-2]
+				if term == "&" {
+					stack = append(stack, a && b)
+				} else {
+					stack = append(stack, a || b)
+				}
+			default:
+				return false, fmt.Errorf("invalid domain operator %q", term)
+			}
+		case []any:
+			if len(term) != 3 {
+				return false, fmt.Errorf("invalid domain leaf %v", term)
+			}
+			field, _ := term[0].(string)
+			operator, _ := term[1].(string)
+			ok, err := f.leaf(model, record, field, strings.ToLower(operator), term[2])
+			if err != nil {
+				return false, err
+			}
+			stack = append(stack, ok)
+		default:
+			return false, fmt.Errorf("invalid domain term %v", term)
+		}
+	}
+	for _, ok := range stack {
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// resolve sigue un camino con puntos (categ_id.name) y devuelve los valores finales
+// junto con el modelo al que pertenece el último campo.
+func (f *FakeServer) resolve(model string, records []Record, path string) ([]any, string, string) {
+	field, rest, nested := strings.Cut(path, ".")
+	comodel := f.relations[model][field]
+	if !nested {
+		var values []any
+		for _, record := range records {
+			switch v := record[field].(type) {
+			case []int64:
+				for _, id := range v {
+					values = append(values, id)
+				}
+			default:
+				values = append(values, v)
+			}
+		}
+		return values, model, field
+	}
+	var next []Record
+	for _, record := range records {
+		for _, id := range toInts(record[field]) {
+			if related := f.find(comodel, id); related != nil {
+				next = append(next, related)
+			}
+		}
+	}
+	return f.resolve(comodel, next, rest)
+}
+
+func (f *FakeServer) leaf(model string, record Record, path, operator string, want any) (bool, error) {
+	values, lastModel, lastField := f.resolve(model, []Record{record}, path)
+	if operator == "child_of" || operator == "parent_of" {
+		comodel := f.relations[lastModel][lastField]
+		if comodel == "" {
+			comodel = lastModel
+		}
+		for _, value := range values {
+			for _, target := range toInts(want) {
+				if operator == "child_of" && f.descends(comodel, toInt(value), target) ||
+					operator == "parent_of" && f.descends(comodel, target, toInt(value)) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+	negative := operator == "!=" || operator == "not in" || operator == "not like" || operator == "not ilike"
+	if len(values) == 0 {
+		values = []any{nil}
+	}
+	// En campos x2many basta con que un valor cumpla; en los negativos, ninguno debe incumplir.
+	for _, value := range values {
+		ok, err := compare(value, operator, want)
+		if err != nil {
+			return false, err
+		}
+		if ok && !negative {
+			return true, nil
+		}
+		if !ok && negative {
+			return false, nil
+		}
+	}
+	return negative, nil
+}
+
+// descends indica si id es target o uno de sus descendientes según parent_id.
+func (f *FakeServer) descends(model string, id, target int64) bool {
+	for seen := 0; id != 0 && seen < 100; seen++ {
+		if id == target {
+			return true
+		}
+		record := f.find(model, id)
+		if record == nil {
+			return false
+		}
+		id = toInt(record["parent_id"])
+	}
+	return false
+}
+
+func compare(value any, operator string, want any) (bool, error) {
+	switch operator {
+	case "=", "==":
+		return equal(value, want), nil
+	case "!=", "<>":
+		return !equal(value, want), nil
+	case "in", "not in":
+		list, _ := want.([]any)
+		for _, candidate := range list {
+			if equal(value, candidate) {
+				return operator == "in", nil
+			}
+		}
+		return operator == "not in", nil
+	case "like", "ilike", "not like", "not ilike", "=like", "=ilike":
+		s, _ := value.(string)
+		pattern, _ := want.(string)
+		if strings.Contains(operator, "ilike") {
+			s, pattern = strings.ToLower(s), strings.ToLower(pattern)
+		}
+		var ok bool
+		if strings.HasPrefix(operator, "=") {
+			ok = likeMatch(s, pattern)
+		} else {
+			ok = strings.Contains(s, pattern)
+		}
+		if strings.HasPrefix(operator, "not") {
+			return !ok, nil
+		}
+		return ok, nil
+	case "<", "<=", ">", ">=":
+		if value == nil || value == false {
+			return false, nil
+		}
+		c := order(value, want)
+		switch operator {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+	return false, fmt.Errorf("operator %q not implemented by the fake server", operator)
+}
+
+// likeMatch implementa =like con los comodines % y _ y, como Postgres, \ para escaparlos.
+func likeMatch(s, pattern string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '\\':
+		if len(pattern) > 1 {
+			return s != "" && s[0] == pattern[1] && likeMatch(s[1:], pattern[2:])
+		}
+		return s == "\\"
+	case '%':
+		for i := 0; i <= len(s); i++ {
+			if likeMatch(s[i:], pattern[1:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		return s != "" && likeMatch(s[1:], pattern[1:])
+	default:
+		return s != "" && s[0] == pattern[0] && likeMatch(s[1:], pattern[1:])
+	}
+}
+
+func isEmpty(v any) bool {
+	return v == nil || v == false
+}
+
+func equal(a, b any) bool {
+	if isEmpty(a) || isEmpty(b) {
+		return isEmpty(a) && isEmpty(b)
+	}
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func order(a, b any) int {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1
+			case af > bf:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func (f *FakeServer) sortRecords(model string, records []Record, orderBy string) {
+	if orderBy == "" {
+		orderBy = "id"
+	}
+	type key struct {
+		field string
+		desc  bool
+	}
+	var keys []key
+	for _, part := range strings.Split(orderBy, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		keys = append(keys, key{field: fields[0], desc: len(fields) > 1 && strings.EqualFold(fields[1], "desc")})
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, k := range keys {
+			a, b := f.sortValue(model, records[i], k.field), f.sortValue(model, records[j], k.field)
+			if isEmpty(a) && isEmpty(b) {
+				continue
+			}
+			// Como en Postgres, los vacíos van al final en ASC.
+			if isEmpty(a) || isEmpty(b) {
+				return isEmpty(b) != k.desc
+			}
+			if c := order(a, b); c != 0 {
+				return (c < 0) != k.desc
+			}
+		}
+		return false
+	})
+}
+
+// sortValue ordena los many2one por el nombre del registro relacionado, como Odoo.
+func (f *FakeServer) sortValue(model string, record Record, field string) any {
+	if comodel := f.relations[model][field]; comodel != "" {
+		if related := f.find(comodel, toInt(record[field])); related != nil {
+			return displayName(related)
+		}
+	}
+	return record[field]
+}
+
+func window(records []Record, offset, limit int64) []Record {
+	if offset > int64(len(records)) {
+		return nil
+	}
+	records = records[offset:]
+	if limit > 0 && limit < int64(len(records)) {
+		records = records[:limit]
+	}
+	return records
+}
+
+func displayName(record Record) string {
+	for _, field := range []string{"display_name", "complete_name", "name"} {
+		if name, ok := record[field].(string); ok {
+			return name
+		}
+	}
+	return fmt.Sprint(record["id"])
+}
+
+// render devuelve los registros como los devuelve Odoo: many2one como [id, nombre],
+// x2many como lista de ids y los vacíos como false.
+func (f *FakeServer) render(model string, records []Record, fields []string) []map[string]any {
+	out := make([]map[string]any, 0, len(records))
+	for _, record := range records {
+		row := map[string]any{"id": record["id"]}
+		names := fields
+		if len(names) == 0 {
+			for name := range record {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			row[name] = f.renderValue(model, name, record[name])
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+func (f *FakeServer) renderValue(model, field string, value any) any {
+	if value == nil {
+		return false
+	}
+	comodel, relational := f.relations[model][field]
+	if !relational {
+		return value
+	}
+	if ids, ok := value.([]int64); ok {
+		return ids
+	}
+	id := toInt(value)
+	if id == 0 {
+		return false
+	}
+	name := ""
+	if related := f.find(comodel, id); related != nil {
+		name = displayName(related)
+	}
+	return []any{id, name}
+}
+
+// readGroup agrupa por un solo campo y suma los agregados "campo:sum".
+func (f *FakeServer) readGroup(model string, records []Record, fields, groupBy []string, orderBy string, offset, limit int64) ([]map[string]any, error) {
+	if len(groupBy) != 1 {
+		return nil, fmt.Errorf("read_group in the fake server supports exactly one groupby field")
+	}
+	group := groupBy[0]
+	type aggregate struct{ field, as string }
+	var sums []aggregate
+	for _, field := range fields {
+		name, fn, ok := strings.Cut(field, ":")
+		if ok && fn != "sum" {
+			return nil, fmt.Errorf("aggregate %q not implemented by the fake server", fn)
+		}
+		if ok || name != group {
+			sums = append(sums, aggregate{field: name, as: name})
+		}
+	}
+
+	var keys []any
+	rows := map[string]Record{}
+	for _, record := range records {
+		key := fmt.Sprint(record[group])
+		row, ok := rows[key]
+		if !ok {
+			row = Record{"id": int64(len(keys) + 1), group: record[group], "__count": int64(0)}
+			rows[key] = row
+			keys = append(keys, key)
+		}
+		row["__count"] = row["__count"].(int64) + 1
+		for _, agg := range sums {
+			value, _ := toFloat(record[agg.field])
+			current, _ := toFloat(row[agg.as])
+			row[agg.as] = current + value
+		}
+	}
+
+	grouped := make([]Record, 0, len(keys))
+	for _, key := range keys {
+		grouped = append(grouped, rows[key.(string)])
+	}
+	f.sortRecords(model, grouped, orderBy)
+	grouped = window(grouped, offset, limit)
+
+	out := make([]map[string]any, 0, len(grouped))
+	for _, row := range grouped {
+		item := map[string]any{"__count": row["__count"], group: f.renderValue(model, group, row[group])}
+		for _, agg := range sums {
+			item[agg.as] = row[agg.as]
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toInt(v any) int64 {
+	switch n := v.(type) {
+	case []any:
+		// Un many2one renderizado [id, nombre].
+		if len(n) > 0 {
+			return toInt(n[0])
+		}
+		return 0
+	}
+	f, _ := toFloat(v)
+	return int64(f)
+}
+
+func toInts(v any) []int64 {
+	switch n := v.(type) {
+	case []int64:
+		return n
+	case []any:
+		ids := make([]int64, 0, len(n))
+		for _, id := range n {
+			ids = append(ids, toInt(id))
+		}
+		return ids
+	case nil, bool:
+		return nil
+	}
+	if id := toInt(v); id != 0 {
+		return []int64{id}
+	}
+	return nil
+}
+
+func toStrings(v any) []string {
+	list, _ := v.([]any)
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package odoorpctest
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// SeedCatalog declara las relaciones de los modelos del catálogo y carga un catálogo de
+// demostración pequeño: categorías, productos con stock, un kit, atributos, una tarifa
+// con descuentos, empaquetados y entregas para los más vendidos.
+func SeedCatalog(f *FakeServer) {
+	for model, fields := range map[string]map[string]string{
+		"product.product": {
+			"product_tmpl_id":                      "product.template",
+			"categ_id":                             "product.category",
+			"uom_id":                               "uom.uom",
+			"company_id":                           "res.company",
+			"product_template_attribute_value_ids": "product.template.attribute.value",
+		},
+		"product.template":                 {"categ_id": "product.category", "uom_id": "uom.uom"},
+		"product.category":                 {"parent_id": "product.category"},
+		"product.attribute.value":          {"attribute_id": "product.attribute"},
+		"product.template.attribute.value": {"attribute_id": "product.attribute", "product_attribute_value_id": "product.attribute.value", "product_tmpl_id": "product.template"},
+		"product.pricelist.item":           {"pricelist_id": "product.pricelist", "product_id": "product.product", "product_tmpl_id": "product.template", "categ_id": "product.category"},
+		"product.packaging":                {"product_id": "product.product"},
+		"mrp.bom":                          {"product_tmpl_id": "product.template", "product_id": "product.product"},
+		"mrp.bom.line":                     {"bom_id": "mrp.bom", "product_id": "product.product"},
+		"stock.move":                       {"product_id": "product.product", "location_dest_id": "stock.location"},
+	} {
+		for field, comodel := range fields {
+			f.Relate(model, field, comodel)
+		}
+	}
+
+	f.Add("stock.location",
+		Record{"id": 5, "name": "Partners/Customers"},
+		Record{"id": 8, "name": "WH/Stock"},
+	)
+	f.Add("uom.uom",
+		Record{"id": 1, "name": "Unidades", "rounding": 1.0},
+		Record{"id": 3, "name": "kg", "rounding": 0.001},
+	)
+	f.Add("product.category",
+		Record{"id": 1, "name": "All", "complete_name": "All", "parent_path": "1/"},
+		Record{"id": 2, "name": "Cosméticos", "complete_name": "All / Cosméticos", "parent_id": 1, "parent_path": "1/2/"},
+		Record{"id": 3, "name": "Cremas", "complete_name": "All / Cosméticos / Cremas", "parent_id": 2, "parent_path": "1/2/3/"},
+		Record{"id": 4, "name": "Labiales", "complete_name": "All / Cosméticos / Labiales", "parent_id": 2, "parent_path": "1/2/4/"},
+	)
+	f.Add("product.template",
+		Record{"id": 1, "name": "Crema hidratante", "categ_id": 3, "uom_id": 1, "list_price": 12.5},
+		Record{"id": 2, "name": "Crema solar SPF50", "categ_id": 3, "uom_id": 1, "list_price": 18.0},
+		Record{"id": 3, "name": "Labial", "categ_id": 4, "uom_id": 1, "list_price": 7.0},
+		Record{"id": 4, "name": "Kit de cuidado facial", "categ_id": 2, "uom_id": 1, "list_price": 25.0},
+		Record{"id": 5, "name": "Jabón a granel", "categ_id": 2, "uom_id": 3, "list_price": 4.0},
+		Record{"id": 6, "name": "Material de oficina", "categ_id": 1, "uom_id": 1, "list_price": 2.0, "sale_ok": false},
+	)
+	product := func(id, tmpl, categ, uom int64, name string, price, qty float64, ptavs ...int64) Record {
+		return Record{
+			"id": id, "name": name, "display_name": name, "product_tmpl_id": tmpl, "categ_id": categ, "uom_id": uom,
+			"list_price": price, "qty_available": qty, "sale_ok": true, "active": true, "is_published": true,
+			"image_1920": false, "product_template_attribute_value_ids": append([]int64{}, ptavs...),
+			"create_date": "2024-01-01 10:00:00",
+		}
+	}
+	internal := product(7, 6, 1, 1, "Material de oficina", 2.0, 40)
+	internal["sale_ok"] = false
+	f.Add("product.product",
+		product(1, 1, 3, 1, "Crema hidratante", 12.5, 20),
+		product(2, 2, 3, 1, "Crema solar SPF50", 18.0, 5),
+		product(3, 3, 4, 1, "Labial (Rojo)", 7.0, 12, 1),
+		product(4, 3, 4, 1, "Labial (Rosa)", 7.0, 0, 2),
+		product(5, 4, 2, 1, "Kit de cuidado facial", 25.0, 5),
+		product(6, 5, 2, 3, "Jabón a granel", 4.0, 10.2504),
+		internal,
+	)
+
+	f.Add("product.attribute",
+		Record{"id": 1, "name": "Color", "create_variant": "always", "sequence": 1},
+	)
+	f.Add("product.attribute.value",
+		Record{"id": 1, "name": "Rojo", "attribute_id": 1, "sequence": 1},
+		Record{"id": 2, "name": "Rosa", "attribute_id": 1, "sequence": 2},
+	)
+	f.Add("product.template.attribute.value",
+		Record{"id": 1, "name": "Rojo", "attribute_id": 1, "product_attribute_value_id": 1, "product_tmpl_id": 3, "ptav_active": true},
+		Record{"id": 2, "name": "Rosa", "attribute_id": 1, "product_attribute_value_id": 2, "product_tmpl_id": 3, "ptav_active": true},
+	)
+
+	f.Add("product.pricelist", Record{"id": 1, "name": "Tarifa pública", "active": true})
+	item := func(id int64, appliedOn, compute string, values Record) Record {
+		record := Record{
+			"id": id, "pricelist_id": 1, "applied_on": appliedOn, "compute_price": compute, "base": "list_price",
+			"fixed_price": 0.0, "percent_price": 0.0, "price_discount": 0.0, "price_surcharge": 0.0, "min_quantity": 0.0,
+		}
+		for key, value := range values {
+			record[key] = value
+		}
+		return record
+	}
+	f.Add("product.pricelist.item",
+		item(1, "2_product_category", "percentage", Record{"categ_id": 3, "percent_price": 10.0, "date_end": "2099-12-31 23:59:59"}),
+		item(2, "0_product_variant", "fixed", Record{"product_id": 3, "fixed_price": 5.0}),
+	)
+	f.Add("product.packaging",
+		Record{"id": 1, "product_id": 1, "name": "Caja de 12", "qty": 12.0, "barcode": "7501234567890", "sales": true, "sequence": 1},
+	)
+
+	f.Add("mrp.bom", Record{"id": 1, "product_tmpl_id": 4, "product_id": false, "type": "phantom", "product_qty": 1.0, "sequence": 1, "active": true})
+	f.Add("mrp.bom.line",
+		Record{"id": 1, "bom_id": 1, "product_id": 1, "product_qty": 1.0, "sequence": 1},
+		Record{"id": 2, "bom_id": 1, "product_id": 2, "product_qty": 1.0, "sequence": 2},
+	)
+
+	f.Add("stock.move",
+		Record{"id": 1, "product_id": 1, "location_dest_id": 5, "state": "done", "quantity_done": 30.0},
+		Record{"id": 2, "product_id": 3, "location_dest_id": 5, "state": "done", "quantity_done": 50.0},
+		Record{"id": 3, "product_id": 2, "location_dest_id": 5, "state": "done", "quantity_done": 10.0},
+		Record{"id": 4, "product_id": 2, "location_dest_id": 8, "state": "done", "quantity_done": 100.0},
+	)
+}
+
+// SeedSales declara los modelos de venta (clientes, presupuestos y sus líneas, pedidos del
+// punto de venta y albaranes) y calcula como Odoo, sin impuestos, la referencia, el estado
+// y los importes de cada presupuesto.
+func SeedSales(f *FakeServer) {
+	f.Relate("sale.order", "partner_id", "res.partner")
+	f.Relate("sale.order", "partner_shipping_id", "res.partner")
+	f.Relate("res.partner", "parent_id", "res.partner")
+	f.Relate("sale.order", "pricelist_id", "product.pricelist")
+	f.Relate("sale.order", "company_id", "res.company")
+	f.Relate("sale.order", "currency_id", "res.currency")
+	f.RelateMany("sale.order", "order_line", "sale.order.line", "order_id")
+	f.Relate("sale.order.line", "order_id", "sale.order")
+	f.Relate("sale.order.line", "product_id", "product.product")
+	f.Relate("res.company", "currency_id", "res.currency")
+	f.Relate("pos.order", "partner_id", "res.partner")
+	f.Relate("pos.order", "currency_id", "res.currency")
+	f.Relate("pos.order.line", "order_id", "pos.order")
+	f.Relate("pos.order.line", "product_id", "product.product")
+	f.Relate("stock.picking", "partner_id", "res.partner")
+	f.Relate("stock.picking", "carrier_id", "delivery.carrier")
+
+	f.Add("res.currency", Record{"id": 1, "name": "CUP", "symbol": "$"})
+	f.Add("res.company", Record{"id": 1, "name": "Marcos", "currency_id": 1})
+
+	f.Compute("sale.order.line", func(line Record, find func(string, int64) Record) {
+		product := find("product.product", toInt(line["product_id"]))
+		if product != nil {
+			if line["name"] == nil {
+				line["name"] = product["display_name"]
+			}
+			if line["price_unit"] == nil {
+				line["price_unit"] = product["list_price"]
+			}
+		}
+		price, _ := toFloat(line["price_unit"])
+		qty, _ := toFloat(line["product_uom_qty"])
+		line["price_subtotal"] = math.Round(price*qty*100) / 100
+	})
+	f.Compute("sale.order", func(order Record, find func(string, int64) Record) {
+		if order["name"] == nil {
+			order["name"] = fmt.Sprintf("S%05d", toInt(order["id"]))
+		}
+		if order["state"] == nil {
+			order["state"] = "draft"
+		}
+		if order["currency_id"] == nil {
+			order["currency_id"] = int64(1)
+		}
+		if order["date_order"] == nil {
+			order["date_order"] = time.Now().UTC().Format("2006-01-02 15:04:05")
+		}
+		total := 0.0
+		for _, id := range toInts(order["order_line"]) {
+			if line := find("sale.order.line", id); line != nil {
+				subtotal, _ := toFloat(line["price_subtotal"])
+				total += subtotal
+			}
+		}
+		total = math.Round(total*100) / 100
+		order["amount_untaxed"], order["amount_tax"], order["amount_total"] = total, 0.0, total
+	})
+}
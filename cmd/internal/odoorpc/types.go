@@ -0,0 +1,96 @@
+package odoorpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Odoo devuelve false en lugar de null para los campos vacíos; estos tipos lo aceptan.
+
+// Many2one es un campo many2one tal como lo devuelve search_read: [id, "nombre"] o false.
+type Many2one struct {
+	ID   int64
+	Name string
+}
+
+func (m *Many2one) UnmarshalJSON(data []byte) error {
+	*m = Many2one{}
+	if isFalse(data) {
+		return nil
+	}
+	var pair []any
+	if err := json.Unmarshal(data, &pair); err != nil {
+		// read_group con lazy=false también puede devolver solo el id.
+		return json.Unmarshal(data, &m.ID)
+	}
+	if len(pair) > 0 {
+		if id, ok := pair[0].(float64); ok {
+			m.ID = int64(id)
+		}
+	}
+	if len(pair) > 1 {
+		m.Name, _ = pair[1].(string)
+	}
+	return nil
+}
+
+// MarshalJSON lo devuelve con la misma forma que Odoo.
+func (m Many2one) MarshalJSON() ([]byte, error) {
+	if m.ID == 0 {
+		return []byte("false"), nil
+	}
+	return json.Marshal([]any{m.ID, m.Name})
+}
+
+// String es un campo de texto que puede venir como false.
+type String string
+
+func (s *String) UnmarshalJSON(data []byte) error {
+	if isFalse(data) {
+		*s = ""
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*s = String(value)
+	return nil
+}
+
+// Time es un campo Datetime o Date de Odoo, en UTC ("2006-01-02 15:04:05"), o false.
+type Time struct {
+	time.Time
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	t.Time = time.Time{}
+	if isFalse(data) {
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	for _, layout := range []string{time.DateTime, time.DateOnly} {
+		if parsed, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return &time.ParseError{Layout: time.DateTime, Value: value}
+}
+
+// Ptr devuelve nil si la fecha está vacía.
+func (t Time) Ptr() *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t.Time
+}
+
+func isFalse(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return bytes.Equal(data, []byte("false")) || bytes.Equal(data, []byte("null"))
+}
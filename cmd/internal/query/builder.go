@@ -0,0 +1,139 @@
+// Package query arma las partes variables de las consultas del catálogo
+// (WHERE, ORDER BY, OFFSET/LIMIT) usando solo parámetros enlazados ($1, $2, ...).
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder acumula condiciones y argumentos. Cada valor que viene del cliente entra
+// por Arg, que devuelve su placeholder; nunca se pega texto del cliente en el SQL.
+type Builder struct {
+	args  []any
+	conds []string
+	order string
+	page  string
+}
+
+// Filter es una condición componible que se aplica sobre un Builder.
+type Filter func(b *Builder)
+
+// New crea un Builder vacío.
+func New() *Builder {
+	return &Builder{}
+}
+
+// Arg registra un argumento y devuelve su placeholder ($n).
+func (b *Builder) Arg(value any) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// Where añade una condición; se unen con AND. Las vacías se ignoran.
+func (b *Builder) Where(cond string) *Builder {
+	if cond != "" {
+		b.conds = append(b.conds, cond)
+	}
+	return b
+}
+
+// Apply aplica los filtros en orden; los nil se ignoran.
+func (b *Builder) Apply(filters ...Filter) *Builder {
+	for _, filter := range filters {
+		if filter != nil {
+			filter(b)
+		}
+	}
+	return b
+}
+
+// OrderBy elige el ORDER BY de la lista permitida por su clave.
+// Si la clave no está permitida se usa la entrada con clave "".
+func (b *Builder) OrderBy(key string, allowed map[string]string) *Builder {
+	order, ok := allowed[strings.ToLower(strings.TrimSpace(key))]
+	if !ok {
+		order = allowed[""]
+	}
+	b.order = order
+	return b
+}
+
+// Page añade OFFSET y LIMIT como argumentos enlazados.
+func (b *Builder) Page(offset, limit int) *Builder {
+	if offset < 0 {
+		offset = 0
+	}
+	b.page = fmt.Sprintf(" OFFSET %s LIMIT %s", b.Arg(offset), b.Arg(limit))
+	return b
+}
+
+// WhereClause devuelve " WHERE a AND b" o "" si no hay condiciones.
+func (b *Builder) WhereClause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// OrderClause devuelve " ORDER BY ..." o "" si no se eligió orden.
+func (b *Builder) OrderClause() string {
+	if b.order == "" {
+		return ""
+	}
+	return " ORDER BY " + b.order
+}
+
+// PageClause devuelve " OFFSET $n LIMIT $m" o "" si no se llamó a Page.
+func (b *Builder) PageClause() string {
+	return b.page
+}
+
+// Args devuelve una copia de los argumentos registrados hasta ahora.
+func (b *Builder) Args() []any {
+	return append([]any(nil), b.args...)
+}
+
+// Between limita la columna a [min, max]; cualquiera de los extremos puede ser nil.
+func Between(column string, min, max *int64) Filter {
+	return func(b *Builder) {
+		if min != nil {
+			b.Where(fmt.Sprintf("%s >= %s", column, b.Arg(*min)))
+		}
+		if max != nil {
+			b.Where(fmt.Sprintf("%s <= %s", column, b.Arg(*max)))
+		}
+	}
+}
+
+// Equal limita la columna al valor dado si no es nil.
+func Equal(column string, value *int64) Filter {
+	return func(b *Builder) {
+		if value != nil {
+			b.Where(fmt.Sprintf("%s = %s", column, b.Arg(*value)))
+		}
+	}
+}
+
+// ContainsAny exige que la expresión contenga alguno de los textos (ILIKE, sin distinguir mayúsculas).
+// El texto se pasa como argumento, así que los comodines % y _ del cliente no se interpretan.
+func ContainsAny(expr string, values []string) Filter {
+	return func(b *Builder) {
+		var conds []string
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			conds = append(conds, fmt.Sprintf("%s ILIKE '%%' || %s || '%%'", expr, b.Arg(EscapeLike(value))))
+		}
+		if len(conds) > 0 {
+			b.Where("(" + strings.Join(conds, " OR ") + ")")
+		}
+	}
+}
+
+// EscapeLike escapa los comodines de LIKE (también los de =ilike en los dominios de Odoo)
+// para buscar el texto literal.
+func EscapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,124 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func int64p(v int64) *int64 { return &v }
+
+func TestBuilderPlaceholdersAndArgs(t *testing.T) {
+	b := New()
+	b.Where("p.active")
+	b.Where("")
+	b.Apply(Equal("p.categ_id", int64p(3)), nil, Between("p.list_price", int64p(10), int64p(20)))
+	b.Where("p.name = " + b.Arg("crema"))
+	b.Page(40, 20)
+
+	wantWhere := " WHERE p.active AND p.categ_id = $1 AND p.list_price >= $2 AND p.list_price <= $3 AND p.name = $4"
+	if got := b.WhereClause(); got != wantWhere {
+		t.Errorf("WhereClause() = %q; want %q", got, wantWhere)
+	}
+	if got, want := b.PageClause(), " OFFSET $5 LIMIT $6"; got != want {
+		t.Errorf("PageClause() = %q; want %q", got, want)
+	}
+	wantArgs := []any{int64(3), int64(10), int64(20), "crema", 40, 20}
+	if got := b.Args(); !reflect.DeepEqual(got, wantArgs) {
+		t.Errorf("Args() = %#v; want %#v", got, wantArgs)
+	}
+}
+
+func TestBuilderEmpty(t *testing.T) {
+	b := New()
+	if b.WhereClause() != "" || b.OrderClause() != "" || b.PageClause() != "" || len(b.Args()) != 0 {
+		t.Errorf("empty builder = %q %q %q %v; want no clauses", b.WhereClause(), b.OrderClause(), b.PageClause(), b.Args())
+	}
+	if got, want := b.Page(-5, 10).PageClause(), " OFFSET $1 LIMIT $2"; got != want || b.Args()[0] != 0 {
+		t.Errorf("Page(-5, 10) = %q %v; want %q with offset 0", got, b.Args(), want)
+	}
+}
+
+func TestArgsIsACopy(t *testing.T) {
+	b := New()
+	b.Arg(1)
+	args := b.Args()
+	args[0] = 2
+	if b.Args()[0] != 1 {
+		t.Errorf("Args() shares its backing array with the builder")
+	}
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max *int64
+		where    string
+		args     []any
+	}{
+		{"both", int64p(1), int64p(9), " WHERE price >= $1 AND price <= $2", []any{int64(1), int64(9)}},
+		{"min only", int64p(1), nil, " WHERE price >= $1", []any{int64(1)}},
+		{"max only", nil, int64p(9), " WHERE price <= $1", []any{int64(9)}},
+		{"none", nil, nil, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New().Apply(Between("price", tt.min, tt.max))
+			if got := b.WhereClause(); got != tt.where {
+				t.Errorf("WhereClause() = %q; want %q", got, tt.where)
+			}
+			if got := b.Args(); !reflect.DeepEqual(got, tt.args) {
+				t.Errorf("Args() = %#v; want %#v", got, tt.args)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	b := New().Apply(Equal("categ_id", int64p(7)))
+	if got, want := b.WhereClause(), " WHERE categ_id = $1"; got != want {
+		t.Errorf("WhereClause() = %q; want %q", got, want)
+	}
+	if got := b.Args(); !reflect.DeepEqual(got, []any{int64(7)}) {
+		t.Errorf("Args() = %#v; want [7]", got)
+	}
+	if b := New().Apply(Equal("categ_id", nil)); b.WhereClause() != "" || len(b.Args()) != 0 {
+		t.Errorf("Equal with nil added %q %v", b.WhereClause(), b.Args())
+	}
+}
+
+func TestContainsAny(t *testing.T) {
+	b := New().Apply(ContainsAny("pc.name", []string{" Cremas ", "", "50%_off", `a\b`}))
+	want := " WHERE (pc.name ILIKE '%' || $1 || '%' OR pc.name ILIKE '%' || $2 || '%' OR pc.name ILIKE '%' || $3 || '%')"
+	if got := b.WhereClause(); got != want {
+		t.Errorf("WhereClause() = %q; want %q", got, want)
+	}
+	wantArgs := []any{"Cremas", `50\%\_off`, `a\\b`}
+	if got := b.Args(); !reflect.DeepEqual(got, wantArgs) {
+		t.Errorf("Args() = %#v; want %#v", got, wantArgs)
+	}
+	if b := New().Apply(ContainsAny("pc.name", []string{" ", ""})); b.WhereClause() != "" {
+		t.Errorf("ContainsAny with blank values added %q", b.WhereClause())
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	allowed := map[string]string{
+		"":           "p.id",
+		"price_asc":  "p.list_price ASC, p.id",
+		"price_desc": "p.list_price DESC, p.id",
+	}
+	tests := []struct{ key, want string }{
+		{"price_desc", " ORDER BY p.list_price DESC, p.id"},
+		{" Price_ASC ", " ORDER BY p.list_price ASC, p.id"},
+		{"", " ORDER BY p.id"},
+		{"p.id; DROP TABLE product_product", " ORDER BY p.id"},
+	}
+	for _, tt := range tests {
+		if got := New().OrderBy(tt.key, allowed).OrderClause(); got != tt.want {
+			t.Errorf("OrderBy(%q) = %q; want %q", tt.key, got, tt.want)
+		}
+	}
+	if got := New().OrderBy("unknown", map[string]string{"a": "x"}).OrderClause(); got != "" {
+		t.Errorf("OrderBy without fallback = %q; want no ORDER BY", got)
+	}
+}
@@ -0,0 +1,113 @@
+// Package store define las tiendas (storefronts) que sirve el backend sobre una misma base
+// de Odoo y guarda la tienda de cada petición en su context.
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Header es la cabecera con la que el cliente elige la tienda; tiene prioridad sobre el host.
+const Header = "X-Store"
+
+// Store es una tienda: una compañía de Odoo (res_company) con su ubicación de stock,
+// su tarifa y su moneda. Los ids en 0 dejan el valor global de la configuración.
+type Store struct {
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Hosts       []string `json:"hosts"`
+	CompanyID   int64    `json:"companyId"`
+	LocationID  int64    `json:"locationId"`
+	PricelistID int64    `json:"pricelistId"`
+	Currency    string   `json:"currency"`
+}
+
+// Registry resuelve la tienda por código o por host. La primera tienda es la de por defecto.
+type Registry struct {
+	stores []Store
+	byCode map[string]Store
+	byHost map[string]Store
+}
+
+// New valida las tiendas: el código es obligatorio y ni códigos ni hosts pueden repetirse.
+func New(stores []Store) (*Registry, error) {
+	r := &Registry{byCode: map[string]Store{}, byHost: map[string]Store{}}
+	for _, s := range stores {
+		s.Code = strings.ToLower(strings.TrimSpace(s.Code))
+		if s.Code == "" {
+			return nil, fmt.Errorf("store without code")
+		}
+		if _, ok := r.byCode[s.Code]; ok {
+			return nil, fmt.Errorf("duplicated store %q", s.Code)
+		}
+		for _, host := range s.Hosts {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if other, ok := r.byHost[host]; ok {
+				return nil, fmt.Errorf("host %q used by stores %q and %q", host, other.Code, s.Code)
+			}
+			r.byHost[host] = s
+		}
+		r.byCode[s.Code] = s
+		r.stores = append(r.stores, s)
+	}
+	return r, nil
+}
+
+// Load lee las tiendas de un fichero JSON con una lista de Store.
+func Load(path string) (*Registry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stores file: %w", err)
+	}
+	var stores []Store
+	if err := json.Unmarshal(content, &stores); err != nil {
+		return nil, fmt.Errorf("error parsing stores file: %w", err)
+	}
+	return New(stores)
+}
+
+// All devuelve las tiendas en el orden del fichero.
+func (r *Registry) All() []Store {
+	return r.stores
+}
+
+// Lookup busca la tienda por código.
+func (r *Registry) Lookup(code string) (Store, bool) {
+	s, ok := r.byCode[strings.ToLower(strings.TrimSpace(code))]
+	return s, ok
+}
+
+// Resolve elige la tienda de una petición: la de la cabecera X-Store si viene (ok es false
+// si no existe), si no la del host y, si el host no está mapeado, la de por defecto.
+func (r *Registry) Resolve(header, host string) (Store, bool) {
+	if header != "" {
+		return r.Lookup(header)
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if s, ok := r.byHost[strings.ToLower(host)]; ok {
+		return s, true
+	}
+	if len(r.stores) == 0 {
+		return Store{}, false
+	}
+	return r.stores[0], true
+}
+
+type contextKey struct{}
+
+// WithContext devuelve un context con la tienda de la petición.
+func WithContext(ctx context.Context, s Store) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext devuelve la tienda de la petición, si se resolvió alguna.
+func FromContext(ctx context.Context) (Store, bool) {
+	s, ok := ctx.Value(contextKey{}).(Store)
+	return s, ok
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"log"
 	"os"
+	"strings"
+	"time"
+	// La zona horaria de las tiendas no depende de que el sistema tenga zoneinfo.
+	_ "time/tzdata"
 
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/api"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/handler"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/db"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/env"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/mailer"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/notify"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/service"
 	"github.com/go-chi/chi/v5"
@@ -18,14 +28,20 @@ func main() {
 	env := env.Start()
 
 	api := api.NewApi(env.Addr)
-	connOdoo := db.GetConnectionOdoo(db.DBConfig{
-		Host:     env.DBHost,
-		Port:     env.DBPortOdoo,
-		User:     env.DBUserOdoo,
-		Password: env.DBPassOdoo,
-		Name:     env.DBNameOdoo,
-		SSLMode:  env.SSLMode,
-	})
+	odooConfig := db.DBConfig{
+		Host:             env.DBHost,
+		Port:             env.DBPortOdoo,
+		User:             env.DBUserOdoo,
+		Password:         env.DBPassOdoo,
+		Name:             env.DBNameOdoo,
+		SSLMode:          env.SSLMode,
+		StatementTimeout: env.StatementTimeout,
+		MaxOpenConns:     int(env.DBMaxOpenConns),
+		MaxIdleConns:     int(env.DBMaxIdleConns),
+		ConnMaxLifetime:  env.DBConnMaxLifetime,
+		ConnMaxIdleTime:  env.DBConnMaxIdleTime,
+	}
+	connOdoo := db.GetConnectionOdoo(odooConfig)
 	log.Println("Database connection successful")
 
 	// if err := db.ApplyMigrations(connOdoo, "cmd/internal/db/migration.sql"); err != nil {
@@ -38,19 +54,245 @@ func main() {
 		log.Fatalf("error detecting file/img: %v", err)
 	}
 
-	repositoryOdoo := repository.NewProductRepo(connOdoo)
-	repositoryAdmin := repository.NewAdminRepo(connOdoo)
+	productConfig := repository.ProductRepoConfig{
+		PricelistID:  env.PricelistID,
+		Visibility:   repository.ParseVisibilityFlags(env.CatalogVisibility, env.CompanyID),
+		QueryTimeout: env.QueryTimeout,
+		// Las reservas para recoger en tienda restan del stock del catálogo SQL.
+		Holds: env.ReservationsEnabled,
+	}
+	// Las lecturas del catálogo van a las réplicas; las escrituras (admin, instantánea) al primario.
+	var replicas []string
+	for _, replica := range strings.Split(env.DBReplicas, ",") {
+		if replica = strings.TrimSpace(replica); replica != "" {
+			replicas = append(replicas, replica)
+		}
+	}
+	cluster := db.NewCluster(connOdoo, odooConfig, replicas, env.ReplicaMaxLag)
+	defer cluster.Close()
+	go cluster.Run(context.Background(), env.ReplicaCheckInterval)
+	go db.WatchPools(context.Background(), env.DBPoolWatchInterval, env.DBPoolWaitWarn, cluster.Pools)
+
+	var rpcClient *odoorpc.Client
+	if env.CatalogBackend == "rpc" || env.OrdersEnabled || env.CustomersEnabled {
+		rpcConfig := odoorpc.Config{
+			URL:      env.OdooURL,
+			DB:       env.OdooDB,
+			Username: env.OdooUser,
+			Password: env.OdooPassword,
+			Lang:     env.OdooLang,
+			Timeout:  env.QueryTimeout,
+		}
+		rpcClient = odoorpc.NewClient(rpcConfig)
+		log.Printf("Odoo JSON-RPC at %s", rpcConfig.URL)
+	}
+
+	// La versión de Odoo elige las variantes de las consultas que cambian entre 15, 16 y 17.
+	productConfig.OdooVersion = env.OdooVersion
+	if productConfig.OdooVersion == 0 {
+		versionClient := rpcClient
+		if env.CatalogBackend != "rpc" {
+			versionClient = nil
+		}
+		version, err := detectOdooVersion(connOdoo, versionClient)
+		if err != nil {
+			log.Fatalf("error detecting the Odoo version: %v", err)
+		}
+		productConfig.OdooVersion = version
+	}
+	if err := repository.CheckOdooVersion(productConfig.OdooVersion); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Odoo version: %d", productConfig.OdooVersion)
+
+	repositoryOdoo := repository.NewProductRepo(cluster, productConfig)
+	if env.CatalogBackend == "rpc" {
+		repositoryOdoo = repository.NewRPCProductRepo(rpcClient, productConfig)
+	}
+	var stores *store.Registry
+	if env.StoresFile != "" {
+		var err error
+		if stores, err = store.Load(env.StoresFile); err != nil {
+			log.Fatalf("error loading stores: %v", err)
+		}
+		log.Printf("Serving %d stores", len(stores.All()))
+	}
+
+	repositoryAdmin := repository.NewAdminRepo(connOdoo, env.QueryTimeout)
+	adminHandler := handler.NewAdminHandler(repositoryAdmin, stores)
+
+	adminHandler.AddStats("pools", func() any {
+		return cluster.Pools()
+	})
+	if len(replicas) > 0 {
+		adminHandler.AddStats("replicas", func() any {
+			return cluster.Stats()
+		})
+	}
+
+	var snapshot *repository.SnapshotRepo
+	if env.CatalogSnapshot && env.CatalogBackend != "rpc" {
+		if err := db.ApplyMigrations(connOdoo, "cmd/internal/db/snapshot.sql"); err != nil {
+			log.Fatalf("error creating catalog snapshot: %v", err)
+		}
+		snapshot = repository.NewSnapshotRepo(connOdoo, cluster, productConfig, repositoryOdoo)
+		go snapshot.Run(context.Background(), env.CatalogSnapshotInterval, env.CatalogSnapshotFullInterval)
+		repositoryOdoo = snapshot
+		adminHandler.AddStats("snapshot", func() any {
+			return map[string]any{"ready": snapshot.Ready()}
+		})
+	}
 
 	productService := service.NewProductService(repositoryOdoo)
 
-	productHandlerOdoo := handler.NewProductHandler(productService)
-	adminHandler := handler.NewAdminHandler(repositoryAdmin)
+	var onStockChange func(productID int64)
+	if env.CacheMaxBytes > 0 {
+		cachedService := service.NewCachedProductService(productService, service.CacheConfig{
+			MaxBytes:    env.CacheMaxBytes,
+			List:        env.CacheTTLList,
+			Product:     env.CacheTTLProduct,
+			BestSelling: env.CacheTTLBestSelling,
+			OnSale:      env.CacheTTLOnSale,
+			Categories:  env.CacheTTLCategories,
+			Attributes:  env.CacheTTLAttributes,
+		})
+		productService = cachedService
+
+		var listener *notify.Listener
+		if env.CacheNotify {
+			if env.CacheNotifyTriggers {
+				if err := db.ApplyMigrations(connOdoo, "cmd/internal/db/notify.sql"); err != nil {
+					log.Fatalf("error installing catalog triggers: %v", err)
+				}
+			}
+			listener = notify.NewListener(odooConfig.DSN(), func(ev notify.Event) {
+				cachedService.OnCatalogChange(ev.Table, ev.ProductID)
+			}, func() {
+				// Lo notificado mientras la conexión estaba caída se perdió.
+				cachedService.Invalidate("")
+			})
+			go listener.Run(context.Background())
+		}
+		onStockChange = func(productID int64) {
+			cachedService.OnCatalogChange("stock_quant", productID)
+		}
+		adminHandler.AddStats("cache", func() any {
+			return map[string]any{
+				"stats":    cachedService.CacheStats(),
+				"listener": listener != nil && listener.Up(),
+			}
+		})
+	}
+
+	if snapshot != nil {
+		// Las reservas no tocan Odoo, así que la instantánea no las vería hasta la siguiente
+		// carga completa: se recalcula el producto antes de invalidar la caché.
+		invalidate := onStockChange
+		onStockChange = func(productID int64) {
+			if err := snapshot.RefreshProducts(context.Background(), productID); err != nil {
+				log.Printf("error refreshing product %d in the catalog snapshot: %v", productID, err)
+			}
+			if invalidate != nil {
+				invalidate(productID)
+			}
+		}
+	}
+
+	productHandlerOdoo := handler.NewProductHandler(productService, stores)
 
 	router := chi.NewRouter()
 
+	mail := mailer.New(mailer.Config{Addr: env.SMTPAddr, From: env.SMTPFrom, Username: env.SMTPUser, Password: env.SMTPPassword})
+
 	productHandlerOdoo.RegisterRoutes(router, env)
+	var shops repository.ShopRepo
+	if env.ReservationsEnabled || env.ShopAvailability {
+		hours, err := repository.LoadShopHours(env.ShopHoursFile)
+		if err != nil {
+			log.Fatalf("error loading shop hours: %v", err)
+		}
+		shops = repository.NewShopRepo(cluster, productConfig, hours)
+	}
+	// holds son las reservas vigentes, que POST /orders no puede vender.
+	var holds repository.HeldStock
+	if env.ReservationsEnabled {
+		if err := db.ApplyMigrations(connOdoo, "cmd/internal/db/reservation.sql"); err != nil {
+			log.Fatalf("error creating reservation tables: %v", err)
+		}
+		if env.CatalogBackend == "rpc" {
+			log.Println("Reservations are not subtracted from the catalog stock with the rpc backend")
+		}
+		reservations := repository.NewReservationRepo(connOdoo, productConfig)
+		holds = reservations
+		reservationService := service.NewReservationService(
+			reservations,
+			shops,
+			mail, env.ReservationTTL, onStockChange,
+		)
+		go reservationService.Run(context.Background(), env.ReservationSweepInterval)
+		reservationHandler := handler.NewReservationHandler(reservationService, env.TrackingRateLimit)
+		reservationHandler.RegisterRoutes(router)
+		adminHandler.AddRoutes(reservationHandler.RegisterAdminRoutes)
+	}
+	if env.DeliveryZones {
+		if err := db.ApplyMigrations(connOdoo, "cmd/internal/db/delivery.sql"); err != nil {
+			log.Fatalf("error creating delivery zone tables: %v", err)
+		}
+		location, err := time.LoadLocation(env.TimeZone)
+		if err != nil {
+			log.Fatalf("error loading time zone %q: %v", env.TimeZone, err)
+		}
+		deliveryHandler := handler.NewDeliveryHandler(service.NewDeliveryService(
+			repository.NewAddressRepo(cluster, productConfig),
+			repository.NewDeliveryZoneRepo(connOdoo, env.QueryTimeout),
+			repository.NewPriceRepo(cluster, productConfig),
+			location,
+		))
+		deliveryHandler.RegisterRoutes(router)
+		adminHandler.AddRoutes(deliveryHandler.RegisterAdminRoutes)
+	}
+	if env.ShopAvailability {
+		handler.NewAvailabilityHandler(service.NewAvailabilityService(shops, env.AvailabilityFewLeft)).RegisterRoutes(router)
+	}
 	adminHandler.RegisterRoutes(router)
+	if env.OrdersEnabled {
+		orderService := service.NewOrderService(repository.NewRPCOrderRepo(rpcClient, productConfig, holds))
+		handler.NewOrderHandler(orderService).RegisterRoutes(router)
+	}
+	if env.OrdersEnabled || env.CustomersEnabled {
+		trackingService := service.NewTrackingService(repository.NewRPCTrackingRepo(rpcClient, productConfig, env.CarrierTracking))
+		handler.NewTrackingHandler(trackingService, env.TrackingRateLimit).RegisterRoutes(router)
+	}
+	if env.CustomersEnabled {
+		if err := db.ApplyMigrations(connOdoo, "cmd/internal/db/customer.sql"); err != nil {
+			log.Fatalf("error creating customer tables: %v", err)
+		}
+		customerService := service.NewCustomerService(
+			repository.NewCustomerRepo(connOdoo, env.QueryTimeout, env.CustomerVerifyTTL),
+			repository.NewRPCPartnerRepo(rpcClient, productConfig),
+			repository.NewRPCOrderHistoryRepo(rpcClient, productConfig, env.POSOrders),
+			repository.NewRPCTrackingRepo(rpcClient, productConfig, env.CarrierTracking),
+			mail,
+			service.CustomerConfig{VerifyURL: env.AddrClient + "/verify-email", TokenTTL: env.CustomerVerifyTTL},
+		)
+		handler.NewCustomerHandler(customerService, env.CustomerSecret, env.CustomerSessionTTL, env.AccountRateLimit).RegisterRoutes(router)
+	}
 
 	log.Fatal("Error in server ", api.Run(router))
 
 }
+
+// detectOdooVersion pregunta la versión a la API si el catálogo va por JSON-RPC y,
+// si no, la lee de la base de datos.
+func detectOdooVersion(conn *sql.DB, client *odoorpc.Client) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if client != nil {
+		version, err := client.Version(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return repository.ParseOdooVersion(version)
+	}
+	return repository.DetectOdooVersion(ctx, conn)
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/andybalholm/brotli"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// compressibleTypes son los tipos que se comprimen. Las imágenes PNG/JPEG ya vienen
+// comprimidas y el streaming SSE no debe retenerse, así que no se incluyen.
+var compressibleTypes = []string{"application/json", "text/plain", "text/html", "image/svg+xml"}
+
+// Compress comprime las respuestas con brotli o gzip según Accept-Encoding
+// (brotli tiene preferencia). level se aplica a ambos codificadores.
+func Compress(level int) func(http.Handler) http.Handler {
+	compressor := chimiddleware.NewCompressor(level, compressibleTypes...)
+	compressor.SetEncoder("br", func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, level)
+	})
+	return compressor.Handler
+}
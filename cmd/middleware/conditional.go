@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/cache"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
+)
+
+// validatorTTL es cuánto se recuerda la fecha de modificación de cada URL.
+const validatorTTL = 24 * time.Hour
+
+// Validator es lo que se recuerda de la última respuesta de una URL.
+type Validator struct {
+	ETag     string    `json:"etag"`
+	Modified time.Time `json:"modified"`
+}
+
+// conditionalResponse retiene la respuesta para calcular su ETag antes de enviarla, salvo
+// que el handler ya lo haya puesto: entonces las precondiciones se evalúan al escribir las
+// cabeceras y el cuerpo pasa sin copiarse, como en los listados en streaming.
+type conditionalResponse struct {
+	http.ResponseWriter
+	r          *http.Request
+	validators *cache.Cache
+	status     int
+	// streaming indica que las cabeceras ya se decidieron; con discard es un 304 y el
+	// cuerpo se descarta.
+	streaming bool
+	discard   bool
+	body      bytes.Buffer
+}
+
+func (c *conditionalResponse) WriteHeader(status int) {
+	if c.status != 0 {
+		return
+	}
+	c.status = status
+	etag := c.Header().Get("ETag")
+	if status != http.StatusOK || etag == "" {
+		return
+	}
+	c.streaming = true
+	// Cada codificación es una representación distinta y necesita su propio ETag.
+	if encoding := c.Header().Get("Content-Encoding"); encoding != "" {
+		etag = strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+	}
+	if c.validate(etag) {
+		c.discard = true
+		return
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *conditionalResponse) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.WriteHeader(http.StatusOK)
+	}
+	switch {
+	case c.discard:
+		return len(p), nil
+	case c.streaming:
+		return c.ResponseWriter.Write(p)
+	}
+	return c.body.Write(p)
+}
+
+// validate pone ETag, Last-Modified y Cache-Control y, si el cliente ya tiene esa versión,
+// responde 304 y devuelve true. Last-Modified es el momento en que esta instancia vio
+// cambiar el ETag de la URL.
+func (c *conditionalResponse) validate(etag string) bool {
+	w, r := c.ResponseWriter, c.r
+	w.Header().Set("ETag", etag)
+
+	// Cada tienda y cada codificación tienen su propia versión de la URL.
+	key := r.Host + r.URL.RequestURI() + " " + w.Header().Get("Content-Encoding")
+	if s, ok := store.FromContext(r.Context()); ok {
+		key += " " + s.Code
+	}
+	modified := time.Now().UTC().Truncate(time.Second)
+	if value, ok := c.validators.Get(key); ok && value.(Validator).ETag == etag {
+		modified = value.(Validator).Modified
+	} else {
+		c.validators.Set(key, Validator{ETag: etag, Modified: modified}, validatorTTL)
+	}
+	w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	if w.Header().Get("Cache-Control") == "" {
+		// El cliente puede guardar la respuesta pero debe revalidarla siempre.
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if !notModified(r, etag, modified) {
+		return false
+	}
+	w.Header().Del("Content-Type")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ConditionalGET añade ETag y Last-Modified a las respuestas 200 de GET y HEAD, y responde
+// 304 cuando If-None-Match o If-Modified-Since indican que el cliente ya tiene esa versión.
+// Si el handler no pone el ETag, se calcula con el hash del contenido, reteniendo la
+// respuesta completa; las respuestas grandes o en streaming deben ponerlo ellas a partir
+// de sus datos. Tras reiniciar, Last-Modified se toma de la hora actual.
+func ConditionalGET() func(http.Handler) http.Handler {
+	validators := cache.New(1 << 20)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			response := &conditionalResponse{ResponseWriter: w, r: r, validators: validators}
+			next.ServeHTTP(response, r)
+			if response.status == 0 {
+				response.status = http.StatusOK
+			}
+			if response.streaming {
+				return
+			}
+			if response.status != http.StatusOK {
+				w.WriteHeader(response.status)
+				w.Write(response.body.Bytes())
+				return
+			}
+
+			sum := sha256.Sum256(response.body.Bytes())
+			if response.validate(`"` + hex.EncodeToString(sum[:16]) + `"`) {
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write(response.body.Bytes())
+		})
+	}
+}
+
+// notModified aplica las precondiciones de RFC 9110: If-None-Match tiene prioridad y,
+// si no viene, se usa If-Modified-Since.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !modified.After(since)
+	}
+	return false
+}
@@ -15,6 +15,11 @@ func RecoverPanic() func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if rec := recover(); rec != nil {
+					// ErrAbortHandler corta a propósito una respuesta ya empezada; net/http
+					// cierra la conexión sin registrarlo.
+					if rec == http.ErrAbortHandler {
+						panic(rec)
+					}
 					log.Printf("panic recovered: %v", rec)
 					render.Status(r, http.StatusInternalServerError)
 					render.JSON(w, r, map[string]string{"error": "internal server error"})
@@ -28,8 +33,8 @@ func CORSmiddleware(env *env.Env) func(http.Handler) http.Handler {
 	return cors.Handler(cors.Options{
 		AllowedOrigins:   []string{env.AddrClient},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-None-Match", "If-Modified-Since", "X-Store", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Link", "ETag", "Last-Modified"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// RateLimit deja pasar como mucho requests peticiones por IP en cada ventana de window
+// y responde 429 con Retry-After al resto. La IP es la de la conexión (RemoteAddr).
+func RateLimit(requests int, window time.Duration) func(http.Handler) http.Handler {
+	var (
+		mu     sync.Mutex
+		start  = time.Now()
+		counts = make(map[string]int)
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := r.RemoteAddr
+			if host, _, err := net.SplitHostPort(ip); err == nil {
+				ip = host
+			}
+
+			mu.Lock()
+			now := time.Now()
+			// Ventana fija: al terminar se olvidan todos los contadores.
+			if now.Sub(start) >= window {
+				start = now
+				clear(counts)
+			}
+			counts[ip]++
+			exceeded := counts[ip] > requests
+			retry := window - now.Sub(start)
+			mu.Unlock()
+
+			if exceeded {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds())+1))
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, map[string]string{"error": "You have exceeded the limit of requests, please try again later"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
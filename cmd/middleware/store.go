@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
+	"github.com/go-chi/render"
+)
+
+// StoreContext resuelve la tienda de cada petición (cabecera X-Store o host) y la guarda
+// en su context. Sin tiendas configuradas no hace nada y el backend sirve una sola tienda.
+func StoreContext(stores *store.Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if stores == nil || len(stores.All()) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// La respuesta depende de la tienda, que puede venir en la cabecera.
+			w.Header().Add("Vary", store.Header)
+			s, ok := stores.Resolve(r.Header.Get(store.Header), r.Host)
+			if !ok {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, map[string]string{"error": "unknown store"})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(store.WithContext(r.Context(), s)))
+		})
+	}
+}
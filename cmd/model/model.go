@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type ProductsResult struct {
 	Products []ProductDTO `json:"products"`
 	Total    uint         `json:"total" db:"total"`
@@ -10,9 +12,43 @@ type ProductDTO struct {
 	Name          string   `json:"name" db:"name"`
 	OriginalPrice float64  `json:"originalPrice" db:"price"`
 	Price         float64  `json:"price"`
-	Category      string   `json:"category"`
-	CategoryName  string   `json:"categoryName" db:"category_name"`
-	Stock         float64  `json:"stock" db:"stock"`
+	// Currency es la moneda de los precios según la tienda de la petición (p. ej. "CUP").
+	Currency     string  `json:"currency,omitempty"`
+	Category     string  `json:"category"`
+	CategoryName string  `json:"categoryName" db:"category_name"`
+	Stock        float64 `json:"stock" db:"stock"`
+	// Discount es el porcentaje de descuento sobre OriginalPrice según la tarifa activa.
+	Discount float64 `json:"discount,omitempty" db:"discount"`
+	// SaleEndDate es la fecha en que termina la promoción, si la regla de la tarifa tiene una.
+	SaleEndDate *time.Time `json:"saleEndDate,omitempty" db:"date_end"`
+	// IsKit indica que el producto es un kit (mrp_bom tipo phantom) y su stock sale de los componentes.
+	IsKit      bool           `json:"isKit,omitempty"`
+	Components []KitComponent `json:"components,omitempty"`
+	// Uom es la unidad de venta; Stock viene redondeado según su Rounding.
+	Uom        *UomDTO        `json:"uom,omitempty"`
+	Packagings []PackagingDTO `json:"packagings,omitempty"`
+}
+
+// UomDTO es una unidad de medida de Odoo (uom_uom).
+type UomDTO struct {
+	Name     string  `json:"name"`
+	Rounding float64 `json:"rounding"`
+}
+
+// PackagingDTO es un empaquetado de venta del producto (product_packaging), p. ej. caja de 12.
+type PackagingDTO struct {
+	ID      int64   `json:"id"`
+	Name    string  `json:"name"`
+	Qty     float64 `json:"qty"`
+	Barcode string  `json:"barcode,omitempty"`
+}
+
+// KitComponent es una línea de la lista de materiales de un kit.
+type KitComponent struct {
+	ProductID int64   `json:"productId"`
+	Name      string  `json:"name"`
+	Quantity  float64 `json:"quantity"`
+	Stock     float64 `json:"stock"`
 }
 
 type ProductDetailDTO struct {
@@ -25,6 +61,18 @@ type ProductDetailDTO struct {
 	Description  string  `json:"description"`
 }
 
+// Attribute es un atributo de producto de Odoo (product_attribute) con sus valores.
+type Attribute struct {
+	ID     int64            `json:"id"`
+	Name   string           `json:"name"`
+	Values []AttributeValue `json:"values"`
+}
+
+type AttributeValue struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
 type BestSellerDTO struct {
 	ProductID   int64   `json:"product_id"`
 	ProductName string  `json:"product_name"`
@@ -36,3 +84,253 @@ type Admin struct {
 	Username string `json:"username" db:"username"`
 	Password string `json:"password" db:"password"`
 }
+
+// OrderRequest es el carrito que envía el cliente a POST /orders.
+type OrderRequest struct {
+	Lines    []OrderLine   `json:"lines"`
+	Customer OrderCustomer `json:"customer"`
+	Note     string        `json:"note,omitempty"`
+}
+
+// OrderLine es una línea del carrito. Price es el precio unitario que vio el cliente;
+// si ya no coincide con el actual, el pedido se rechaza.
+type OrderLine struct {
+	ProductID int64   `json:"productId"`
+	Quantity  float64 `json:"quantity"`
+	Price     float64 `json:"price"`
+}
+
+// OrderCustomer son los datos del cliente; el contacto (res.partner) se busca solo por el
+// email exacto y, si no hay ninguno, se crea.
+type OrderCustomer struct {
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Phone  string `json:"phone,omitempty"`
+	Street string `json:"street,omitempty"`
+	City   string `json:"city,omitempty"`
+}
+
+// OrderResult es el presupuesto (sale.order) creado en Odoo.
+type OrderResult struct {
+	ID          int64   `json:"id"`
+	Reference   string  `json:"reference"`
+	State       string  `json:"state"`
+	AmountTotal float64 `json:"amountTotal"`
+	Currency    string  `json:"currency,omitempty"`
+}
+
+// OrderLineProblem explica por qué una línea del carrito no se puede pedir.
+// Reason es "unavailable", "insufficient_stock" o "price_changed"; Stock y Price son los actuales.
+type OrderLineProblem struct {
+	ProductID int64   `json:"productId"`
+	Reason    string  `json:"reason"`
+	Stock     float64 `json:"stock"`
+	Price     float64 `json:"price"`
+}
+
+// Customer es la cuenta de un cliente de la tienda, enlazada a su contacto de Odoo.
+type Customer struct {
+	ID            int64  `json:"id"`
+	Email         string `json:"email"`
+	Phone         string `json:"phone,omitempty"`
+	Password      string `json:"-"`
+	PartnerID     int64  `json:"-"`
+	EmailVerified bool   `json:"emailVerified"`
+}
+
+// CustomerAddress son los datos del contacto (res.partner) que el cliente puede editar.
+type CustomerAddress struct {
+	Name    string `json:"name"`
+	Phone   string `json:"phone,omitempty"`
+	Street  string `json:"street,omitempty"`
+	Street2 string `json:"street2,omitempty"`
+	City    string `json:"city,omitempty"`
+	Zip     string `json:"zip,omitempty"`
+}
+
+// CustomerSignup es el alta de una cuenta de cliente.
+type CustomerSignup struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	CustomerAddress
+}
+
+// CustomerProfile es la cuenta junto con los datos de su contacto en Odoo.
+type CustomerProfile struct {
+	ID            int64  `json:"id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	CustomerAddress
+}
+
+// CustomerOrders es una página del historial de pedidos de un cliente.
+type CustomerOrders struct {
+	Orders []CustomerOrder `json:"orders"`
+	Total  uint            `json:"total"`
+}
+
+// CustomerOrder es un pedido del cliente: Source es "online" (sale.order) o "store" (pos.order).
+// InvoiceStatus es el de Odoo ("no", "to invoice", "invoiced", "upselling") y DeliveryStatus
+// resume sus albaranes: "pending", "partial", "delivered" o vacío si no lleva entrega.
+type CustomerOrder struct {
+	Reference      string              `json:"reference"`
+	Source         string              `json:"source"`
+	Date           *time.Time          `json:"date,omitempty"`
+	State          string              `json:"state"`
+	InvoiceStatus  string              `json:"invoiceStatus"`
+	DeliveryStatus string              `json:"deliveryStatus,omitempty"`
+	AmountUntaxed  float64             `json:"amountUntaxed"`
+	AmountTax      float64             `json:"amountTax"`
+	AmountTotal    float64             `json:"amountTotal"`
+	Currency       string              `json:"currency,omitempty"`
+	Lines          []CustomerOrderLine `json:"lines"`
+}
+
+// CustomerOrderLine es una línea de un pedido del historial.
+type CustomerOrderLine struct {
+	ProductID         int64   `json:"productId"`
+	Name              string  `json:"name"`
+	Quantity          float64 `json:"quantity"`
+	QuantityDelivered float64 `json:"quantityDelivered"`
+	PriceUnit         float64 `json:"priceUnit"`
+	Subtotal          float64 `json:"subtotal"`
+	Total             float64 `json:"total"`
+}
+
+// Pasos del seguimiento de un pedido, en orden.
+const (
+	TrackingConfirmed = "confirmed"
+	TrackingReady     = "ready"
+	TrackingShipped   = "shipped"
+	TrackingDelivered = "delivered"
+)
+
+// OrderTracking es el seguimiento de un pedido online. Status es el último paso que han
+// alcanzado todas sus entregas, "pending" si el presupuesto no está confirmado o
+// "cancelled" si se canceló.
+type OrderTracking struct {
+	Reference string          `json:"reference"`
+	Status    string          `json:"status"`
+	Timeline  []TrackingStep  `json:"timeline"`
+	Shipments []ShipmentTrack `json:"shipments"`
+}
+
+// ShipmentTrack es un albarán de salida (stock.picking) del pedido. Con transportista,
+// la entrega al cliente se sigue con TrackingRef o TrackingURL.
+type ShipmentTrack struct {
+	Reference     string         `json:"reference"`
+	Status        string         `json:"status"`
+	ScheduledDate *time.Time     `json:"scheduledDate,omitempty"`
+	Carrier       string         `json:"carrier,omitempty"`
+	TrackingRef   string         `json:"trackingRef,omitempty"`
+	TrackingURL   string         `json:"trackingUrl,omitempty"`
+	Timeline      []TrackingStep `json:"timeline"`
+}
+
+// TrackingStep es un paso del seguimiento; At es nil si no se alcanzó o Odoo no guarda la fecha.
+type TrackingStep struct {
+	Status  string     `json:"status"`
+	Reached bool       `json:"reached"`
+	At      *time.Time `json:"at,omitempty"`
+}
+
+// Shop es una tienda física: un punto de venta de Odoo (pos_config, Kind "shop"), donde
+// además se puede recoger, o un almacén sin punto de venta (stock_warehouse, Kind
+// "warehouse"), con la ubicación de stock de la que vende. Address sale del contacto del
+// almacén y OpeningHours del fichero de horarios.
+type Shop struct {
+	ID           int64  `json:"id"`
+	Kind         string `json:"kind"`
+	Name         string `json:"name"`
+	Address      string `json:"address,omitempty"`
+	OpeningHours string `json:"openingHours,omitempty"`
+	LocationID   int64  `json:"-"`
+	CompanyID    int64  `json:"-"`
+}
+
+// Disponibilidad de un producto en una tienda; no se publica la cantidad exacta.
+const (
+	StockAvailable = "available"
+	StockFewLeft   = "few_left"
+	StockOut       = "out_of_stock"
+)
+
+// ShopAvailability es la disponibilidad de un producto en una tienda física.
+type ShopAvailability struct {
+	Shop
+	Status   string  `json:"status"`
+	Quantity float64 `json:"-"`
+}
+
+// ReservationRequest es una reserva para recoger y pagar en una tienda.
+type ReservationRequest struct {
+	ShopID   int64             `json:"shopId"`
+	Lines    []ReservationLine `json:"lines"`
+	Customer OrderCustomer     `json:"customer"`
+}
+
+// ReservationLine es un producto reservado.
+type ReservationLine struct {
+	ProductID int64   `json:"productId"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// Reservation es una reserva con su código de recogida. State es "active", "collected"
+// (pagada en la tienda), "cancelled" (anulada por el cliente) o "expired".
+type Reservation struct {
+	Code         string            `json:"code"`
+	State        string            `json:"state"`
+	Shop         Shop              `json:"shop"`
+	CustomerName string            `json:"customerName"`
+	Email        string            `json:"-"`
+	Phone        string            `json:"-"`
+	ExpiresAt    time.Time         `json:"expiresAt"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	Lines        []ReservationLine `json:"lines"`
+}
+
+// Province es una provincia (res_country_state de Cuba).
+type Province struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+// Municipality es un municipio de l10n_cu_address.
+type Municipality struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	ProvinceID int64  `json:"provinceId"`
+}
+
+// DeliveryZone es una zona de entrega a domicilio. FreeFrom es el subtotal a partir del
+// cual el envío es gratis; nil si nunca lo es.
+type DeliveryZone struct {
+	ID             int64    `json:"id"`
+	Name           string   `json:"name"`
+	Fee            float64  `json:"fee"`
+	FreeFrom       *float64 `json:"freeFrom,omitempty"`
+	LeadTimeDays   int      `json:"leadTimeDays"`
+	Active         bool     `json:"active"`
+	Municipalities []int64  `json:"municipalities"`
+}
+
+// DeliveryQuoteRequest es el carrito y el municipio de entrega que se quieren presupuestar.
+// El precio de las líneas se ignora: el subtotal sale de la tarifa del catálogo.
+type DeliveryQuoteRequest struct {
+	MunicipalityID int64       `json:"municipalityId"`
+	Lines          []OrderLine `json:"lines"`
+}
+
+// DeliveryQuote es el precio del envío de un carrito. Available es false si el municipio
+// no está en ninguna zona activa; entonces solo Subtotal y Total tienen valor.
+type DeliveryQuote struct {
+	MunicipalityID int64      `json:"municipalityId"`
+	Available      bool       `json:"available"`
+	Zone           string     `json:"zone,omitempty"`
+	Subtotal       float64    `json:"subtotal"`
+	Fee            float64    `json:"fee"`
+	Total          float64    `json:"total"`
+	LeadTimeDays   int        `json:"leadTimeDays,omitempty"`
+	EstimatedDate  *time.Time `json:"estimatedDate,omitempty"`
+}
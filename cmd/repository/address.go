@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/db"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// ErrMunicipalityNotFound indica que el municipio no existe.
+var ErrMunicipalityNotFound = errors.New("municipio no encontrado")
+
+// municipalityTable es la tabla de municipios que instala l10n_cu_address
+// (res.country.state.municipality), con su provincia en state_id.
+const municipalityTable = "res_country_state_municipality"
+
+// AddressRepo lee el catálogo de direcciones de Cuba de la base de Odoo: las provincias
+// (res_country_state del país CU) y sus municipios.
+type AddressRepo interface {
+	GetProvinces(ctx context.Context) ([]model.Province, error)
+	GetMunicipalities(ctx context.Context, provinceID int64) ([]model.Municipality, error)
+	GetMunicipality(ctx context.Context, id int64) (*model.Municipality, error)
+}
+
+type odooAddressRepo struct {
+	DB     db.Reader
+	config ProductRepoConfig
+}
+
+// NewAddressRepo construye el repositorio de direcciones sobre la base de Odoo.
+func NewAddressRepo(d db.Reader, config ProductRepoConfig) AddressRepo {
+	return &odooAddressRepo{DB: d, config: config}
+}
+
+func (r *odooAddressRepo) GetProvinces(ctx context.Context) ([]model.Province, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `SELECT s.id, s.name, s.code FROM res_country_state s
+		INNER JOIN res_country c ON c.id = s.country_id
+		WHERE c.code = 'CU' ORDER BY s.name`)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las provincias: %w", err)
+	}
+	defer rows.Close()
+
+	provinces := []model.Province{}
+	for rows.Next() {
+		var p model.Province
+		if err := rows.Scan(&p.ID, &p.Name, &p.Code); err != nil {
+			return nil, fmt.Errorf("error al obtener las provincias: %w", err)
+		}
+		provinces = append(provinces, p)
+	}
+	return provinces, rows.Err()
+}
+
+func (r *odooAddressRepo) GetMunicipalities(ctx context.Context, provinceID int64) ([]model.Municipality, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT id, name, state_id FROM "+municipalityTable+" WHERE state_id = $1 ORDER BY name", provinceID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los municipios: %w", err)
+	}
+	defer rows.Close()
+
+	municipalities := []model.Municipality{}
+	for rows.Next() {
+		var m model.Municipality
+		if err := rows.Scan(&m.ID, &m.Name, &m.ProvinceID); err != nil {
+			return nil, fmt.Errorf("error al obtener los municipios: %w", err)
+		}
+		municipalities = append(municipalities, m)
+	}
+	return municipalities, rows.Err()
+}
+
+func (r *odooAddressRepo) GetMunicipality(ctx context.Context, id int64) (*model.Municipality, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	var m model.Municipality
+	err := r.DB.QueryRowContext(ctx, "SELECT id, name, state_id FROM "+municipalityTable+" WHERE id = $1", id).Scan(&m.ID, &m.Name, &m.ProvinceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMunicipalityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el municipio: %w", err)
+	}
+	return &m, nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -10,7 +11,9 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -21,16 +24,16 @@ type Content struct {
 	Idimg       int64  `json:"idImg"`
 }
 type AdminRepo interface {
-	Authenticate(username, password string) (string, error)
-	ChangePassword(userID int64, oldPassword, newPassword string) error
-	FindImg(id string) (string, error)
-	SaveImg(id int64, path string) (string, error)
-	CreateImg(idContent int64, path, name string) (int64, error)
-	SaveContent(id int64, title, description string) error
-	CreateContent(title, description, location string) (int64, error)
-	GetAllcontent() (map[string][]Content, error)
-	SaveInfo(key, value string) error
-	GetAllinfo() (map[string]string, error)
+	Authenticate(ctx context.Context, username, password string) (string, error)
+	ChangePassword(ctx context.Context, userID int64, oldPassword, newPassword string) error
+	FindImg(ctx context.Context, id string) (string, error)
+	SaveImg(ctx context.Context, id int64, path string) (string, error)
+	CreateImg(ctx context.Context, idContent int64, path, name string) (int64, error)
+	SaveContent(ctx context.Context, id int64, title, description string) error
+	CreateContent(ctx context.Context, title, description, location, code string) (int64, error)
+	GetAllcontent(ctx context.Context) (map[string][]Content, error)
+	SaveInfo(ctx context.Context, key, value string) error
+	GetAllinfo(ctx context.Context) (map[string]string, error)
 }
 
 const UploadDir = "/uploads"
@@ -38,7 +41,8 @@ const UploadDir = "/uploads"
 var aesKey = []byte("aseskeyFromAES")
 
 type sqlAdminRepo struct {
-	db *sql.DB
+	db      *sql.DB
+	timeout time.Duration
 }
 
 func encrypt(plaintext string) (string, error) {
@@ -83,22 +87,43 @@ func decrypt(codestr string) (string, error) {
 	return string(plaintext), nil
 }
 
-func NewAdminRepo(db *sql.DB) *sqlAdminRepo {
+// storeCode devuelve el código de la tienda de la petición, o "" si no hay tienda.
+// El contenido y la información con store NULL se comparten entre todas las tiendas.
+func storeCode(ctx context.Context) string {
+	s, _ := store.FromContext(ctx)
+	return s.Code
+}
+
+// contentScope filtra los contenidos (alias c) que se ven desde la tienda $n, como en
+// GetAllcontent: los compartidos y los de esa tienda, o todos si no hay tiendas.
+func contentScope(n int) string {
+	p := fmt.Sprintf("$%d", n)
+	return "(" + p + " = '' OR c.store IS NULL OR c.store = " + p + ")"
+}
+
+// NewAdminRepo construye el repositorio del CMS; timeout limita cada consulta (0 sin límite).
+func NewAdminRepo(db *sql.DB, timeout time.Duration) *sqlAdminRepo {
 	return &sqlAdminRepo{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
 }
 
-func (r *sqlAdminRepo) Authenticate(username, password string) (string, error) {
+func (r *sqlAdminRepo) Authenticate(ctx context.Context, username, password string) (string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 	if username == "" || password == "" {
 		return "", errors.New("username and password cannot be empty")
 	}
 
 	query := "SELECT username, password FROM UserAdmin WHERE username = $1;"
-	row := r.db.QueryRow(query, username)
-	if row == nil || row.Err() != nil {
+	row := r.db.QueryRowContext(ctx, query, username)
+	if row == nil {
 		return "", errors.New("error retrieving user")
 	}
+	if err := row.Err(); err != nil {
+		return "", fmt.Errorf("error retrieving user: %w", err)
+	}
 	var model model.Admin
 	if err := row.Scan(&model.Username, &model.Password); err != nil {
 		if err == sql.ErrNoRows {
@@ -112,19 +137,22 @@ func (r *sqlAdminRepo) Authenticate(username, password string) (string, error) {
 	return model.Username, nil
 }
 
-func (r *sqlAdminRepo) SaveContent(id int64, title, description string) error {
+func (r *sqlAdminRepo) SaveContent(ctx context.Context, id int64, title, description string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 	if id < 1 {
 		return errors.New("invalid id")
 	}
 
-	query := "UPDATE contents SET title = $1, description = $2 WHERE id = $3;"
-	res, err := r.db.Exec(query, title, description, id)
+	// Desde una tienda solo se edita su contenido y el compartido.
+	query := "UPDATE contents c SET title = $1, description = $2 WHERE c.id = $3 AND " + contentScope(4) + ";"
+	res, err := r.db.ExecContext(ctx, query, title, description, id, storeCode(ctx))
 	if err != nil {
-		return fmt.Errorf("error in query: %v", err)
+		return fmt.Errorf("error in query: %w", err)
 	}
 	n, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error in query: %v", err)
+		return fmt.Errorf("error in query: %w", err)
 	}
 	if n == 0 {
 		return errors.New("content not exist")
@@ -132,29 +160,36 @@ func (r *sqlAdminRepo) SaveContent(id int64, title, description string) error {
 
 	return nil
 }
-func (r *sqlAdminRepo) CreateContent(title, description, location string) (int64, error) {
+
+// CreateContent crea el contenido en la tienda code, o compartido entre todas si code es "".
+// No depende de la tienda de la petición: con tiendas configuradas siempre hay una.
+func (r *sqlAdminRepo) CreateContent(ctx context.Context, title, description, location, code string) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 	location = strings.ToLower(location)
 	if location != "consejo" && location != "negocio" {
 		return -1, errors.New("error not valid location")
 	}
 
-	query := "INSERT INTO contents (title, description,location) VALUES ($1, $2,$3) RETURNING id"
+	query := "INSERT INTO contents (title, description,location, store) VALUES ($1, $2,$3, NULLIF($4, '')) RETURNING id"
 
 	var idReturning int64
-	if err := r.db.QueryRow(query, title, description, location).Scan(&idReturning); err != nil {
-		return -1, fmt.Errorf("error in query: %v", err)
+	if err := r.db.QueryRowContext(ctx, query, title, description, location, code).Scan(&idReturning); err != nil {
+		return -1, fmt.Errorf("error in query: %w", err)
 	}
 	return idReturning, nil
 
 }
 
-func (r *sqlAdminRepo) ChangePassword(userID int64, oldPassword, newPassword string) error {
+func (r *sqlAdminRepo) ChangePassword(ctx context.Context, userID int64, oldPassword, newPassword string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 	if userID <= 0 || oldPassword == "" || newPassword == "" {
 		return errors.New("invalid input parameters")
 	}
 
 	query := "SELECT password FROM UserAdmin WHERE id = $1;"
-	row := r.db.QueryRow(query, userID)
+	row := r.db.QueryRowContext(ctx, query, userID)
 	var hashedPassword string
 	if err := row.Scan(&hashedPassword); err != nil {
 		if err == sql.ErrNoRows {
@@ -173,20 +208,22 @@ func (r *sqlAdminRepo) ChangePassword(userID int64, oldPassword, newPassword str
 	}
 
 	updateQuery := "UPDATE UserAdmin SET password = $1 WHERE id = $2;"
-	if _, err := r.db.Exec(updateQuery, string(newHashedPassword), userID); err != nil {
+	if _, err := r.db.ExecContext(ctx, updateQuery, string(newHashedPassword), userID); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (r *sqlAdminRepo) FindImg(id string) (string, error) {
+func (r *sqlAdminRepo) FindImg(ctx context.Context, id string) (string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 	if len(id) < 1 {
 		return "", errors.New("error in id")
 	}
 	var code string
 	query := "SELECT file_path FROM files WHERE id = $1;"
-	err := r.db.QueryRow(query, id).Scan(&code)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&code)
 	if err != nil {
 		return "", errors.New("img not found")
 	}
@@ -197,14 +234,17 @@ func (r *sqlAdminRepo) FindImg(id string) (string, error) {
 	return path, nil
 }
 
-func (r *sqlAdminRepo) SaveImg(id int64, path string) (string, error) {
+func (r *sqlAdminRepo) SaveImg(ctx context.Context, id int64, path string) (string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 	encPath, err := encrypt(path)
 	if err != nil {
 		return "", err
 	}
+	// La imagen de un contenido de otra tienda no se ve desde esta: no existe.
 	var cypathOldImg string
-	query := "SELECT file_path FROM files WHERE id = $1;"
-	if err = r.db.QueryRow(query, id).Scan(&cypathOldImg); err != nil {
+	query := "SELECT f.file_path FROM files f LEFT JOIN contents c ON c.id = f.content_id WHERE f.id = $1 AND " + contentScope(2) + ";"
+	if err = r.db.QueryRowContext(ctx, query, id, storeCode(ctx)).Scan(&cypathOldImg); err != nil {
 		return "", errors.New("img not exist")
 	}
 	pathOldImg, err := decrypt(cypathOldImg)
@@ -212,48 +252,63 @@ func (r *sqlAdminRepo) SaveImg(id int64, path string) (string, error) {
 		return "", err
 	}
 
-	query = "UPDATE files SET file_path = $1 WHERE id = $2;"
-	_, err = r.db.Exec(query, encPath, id)
+	query = "UPDATE files f SET file_path = $1 WHERE f.id = $2 AND NOT EXISTS (SELECT 1 FROM contents c WHERE c.id = f.content_id AND NOT " + contentScope(3) + ");"
+	res, err := r.db.ExecContext(ctx, query, encPath, id, storeCode(ctx))
 	if err != nil {
-		return "", fmt.Errorf("error in update query: %v", err)
+		return "", fmt.Errorf("error in update query: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return "", errors.New("img not exist")
 	}
 
 	return pathOldImg, nil
 }
-func (r *sqlAdminRepo) CreateImg(idContent int64, path, name string) (int64, error) {
-	query := "INSERT INTO files (name, file_path) VALUES ($1,$2) RETURNING id;"
+func (r *sqlAdminRepo) CreateImg(ctx context.Context, idContent int64, path, name string) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	// La imagen se enlaza al contenido solo si se ve desde la tienda de la petición.
+	query := "INSERT INTO files (name, file_path, content_id) SELECT $1, $2, c.id FROM contents c WHERE c.id = $3 AND " + contentScope(4) + " RETURNING id;"
 	var id int64
-	if err := r.db.QueryRow(query, name, path).Scan(&id); err != nil {
-		return -1, fmt.Errorf("error insert img :%v", err)
+	err := r.db.QueryRowContext(ctx, query, name, path, idContent, storeCode(ctx)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return -1, errors.New("content not exist")
+	}
+	if err != nil {
+		return -1, fmt.Errorf("error insert img : %w", err)
 	}
 	return id, nil
 }
-func (r *sqlAdminRepo) SaveInfo(key, value string) error {
+func (r *sqlAdminRepo) SaveInfo(ctx context.Context, key, value string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 	if len(key) < 2 || len(value) == 0 {
 		return errors.New("error params not valid")
 	}
 	query := "UPDATE files SET name = $1 WHERE key = $2;"
-	res, err := r.db.Exec(query, value, key)
+	res, err := r.db.ExecContext(ctx, query, value, key)
 	if err != nil {
-		return fmt.Errorf("error update info: %v", err)
+		return fmt.Errorf("error update info: %w", err)
 	}
 	n, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error cheking update: %v", err)
+		return fmt.Errorf("error cheking update: %w", err)
 	}
 	if n == 0 {
-		return fmt.Errorf("not exist info: %v", err)
+		return fmt.Errorf("not exist info: %w", err)
 	}
 	return nil
 
 }
-func (r *sqlAdminRepo) GetAllinfo() (map[string]string, error) {
+func (r *sqlAdminRepo) GetAllinfo(ctx context.Context) (map[string]string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 
-	query := "SELECT key,value FROM info;"
-	rows, err := r.db.Query(query)
+	// La información propia de la tienda sustituye a la compartida (store NULL) con la misma clave.
+	query := "SELECT DISTINCT ON (key) key, value FROM info WHERE $1 = '' OR store IS NULL OR store = $1 ORDER BY key, store NULLS LAST;"
+	rows, err := r.db.QueryContext(ctx, query, storeCode(ctx))
 
 	if err != nil {
-		return nil, fmt.Errorf("error in query: %v", err)
+		return nil, fmt.Errorf("error in query: %w", err)
 	}
 	defer rows.Close()
 	var info struct {
@@ -271,11 +326,13 @@ func (r *sqlAdminRepo) GetAllinfo() (map[string]string, error) {
 	return Info, nil
 
 }
-func (r *sqlAdminRepo) GetAllcontent() (map[string][]Content, error) {
-	query := "SELECT c.title, c.description, c.location, f.id as idImg FROM contents c LEFT JOIN files f ON c.id = f.content_id;"
-	rows, err := r.db.Query(query)
+func (r *sqlAdminRepo) GetAllcontent(ctx context.Context) (map[string][]Content, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	query := "SELECT c.title, c.description, c.location, f.id as idImg FROM contents c LEFT JOIN files f ON c.id = f.content_id WHERE $1 = '' OR c.store IS NULL OR c.store = $1;"
+	rows, err := r.db.QueryContext(ctx, query, storeCode(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("error geting content: %v", err)
+		return nil, fmt.Errorf("error geting content: %w", err)
 	}
 	defer rows.Close()
 	ContentMap := make(map[string][]Content)
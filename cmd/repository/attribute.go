@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/query"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// attributeFilter limita la columna (un id de product_product) a los productos que tienen,
+// para cada atributo, alguno de los valores pedidos. Los nombres se comparan con cualquiera
+// de las traducciones del atributo y del valor.
+func attributeFilter(s schema, column string, attributes map[string][]string) query.Filter {
+	return func(b *query.Builder) {
+		names := make([]string, 0, len(attributes))
+		for name, values := range attributes {
+			if len(values) > 0 {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			values := attributes[name]
+			placeholders := make([]string, len(values))
+			attr := b.Arg(name)
+			for i, value := range values {
+				placeholders[i] = b.Arg(value)
+			}
+			b.Where(fmt.Sprintf(`%s IN (SELECT ap.id FROM product_product ap
+			INNER JOIN product_template_attribute_value ptav ON ptav.product_tmpl_id = ap.product_tmpl_id AND ptav.ptav_active
+			INNER JOIN product_attribute_value pav ON pav.id = ptav.product_attribute_value_id
+			INNER JOIN product_attribute pa ON pa.id = ptav.attribute_id
+			WHERE (pa.create_variant = 'no_variant' OR EXISTS (SELECT 1 FROM product_variant_combination pvc WHERE pvc.product_product_id = ap.id AND pvc.product_template_attribute_value_id = ptav.id))
+			AND %s IN (SELECT value FROM jsonb_each_text(%s))
+			AND EXISTS (SELECT 1 FROM jsonb_each_text(%s) WHERE value IN (%s)))`,
+				column, attr, s.name("pa", "product.attribute"), s.name("pav", "product.attribute.value"), strings.Join(placeholders, ", ")))
+		}
+	}
+}
+
+// GetAttributes lista los atributos y los valores usados por algún producto visible.
+func (r *odooProductRepo) GetAttributes(ctx context.Context) ([]model.Attribute, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	query := `SELECT pa.id, ` + translated(r.schema.name("pa", "product.attribute")) + `, pav.id, ` + translated(r.schema.name("pav", "product.attribute.value")) + `
+	FROM product_attribute pa
+	INNER JOIN product_attribute_value pav ON pav.attribute_id = pa.id
+	WHERE EXISTS (SELECT 1 FROM product_template_attribute_value ptav
+		INNER JOIN product_template vt ON vt.id = ptav.product_tmpl_id
+		INNER JOIN product_product vp ON vp.product_tmpl_id = vt.id
+		WHERE ptav.product_attribute_value_id = pav.id AND ptav.ptav_active AND ` + r.config.forStore(ctx).Visibility.condition("vt", "vp") + `)
+	ORDER BY pa.sequence, pa.id, pav.sequence, pav.id;`
+
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los atributos: %w", err)
+	}
+	defer rows.Close()
+
+	attributes := []model.Attribute{}
+	for rows.Next() {
+		var (
+			attrID int64
+			name   string
+			value  model.AttributeValue
+		)
+		if err := rows.Scan(&attrID, &name, &value.ID, &value.Name); err != nil {
+			log.Printf("error al leer el atributo: %v", err)
+			continue
+		}
+		if n := len(attributes); n == 0 || attributes[n-1].ID != attrID {
+			attributes = append(attributes, model.Attribute{ID: attrID, Name: name})
+		}
+		last := &attributes[len(attributes)-1]
+		last.Values = append(last.Values, value)
+	}
+	return attributes, rows.Err()
+}
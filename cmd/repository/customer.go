@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrCustomerExists indica que el email o el teléfono ya tienen una cuenta.
+	ErrCustomerExists = errors.New("ya existe una cuenta con ese email o teléfono")
+	// ErrInvalidCredentials no distingue entre cuenta inexistente y contraseña incorrecta.
+	ErrInvalidCredentials = errors.New("email, teléfono o contraseña incorrectos")
+	// ErrInvalidToken indica un token inexistente, ya usado o caducado.
+	ErrInvalidToken = errors.New("el enlace no es válido o ha caducado")
+	// ErrCustomerNotFound indica que la cuenta de la sesión ya no existe.
+	ErrCustomerNotFound = errors.New("la cuenta no existe")
+)
+
+// TokenVerifyEmail es el propósito de los tokens que verifican el email de una cuenta.
+const TokenVerifyEmail = "verify_email"
+
+// CustomerRepo guarda las cuentas de los clientes (cmd/internal/db/customer.sql).
+type CustomerRepo interface {
+	// Create da de alta la cuenta enlazada al contacto partnerID; devuelve ErrCustomerExists
+	// si el email o el teléfono ya están registrados. Las cuentas abandonadas (ver
+	// NewCustomerRepo) con ese email o teléfono se borran y no cuentan.
+	Create(ctx context.Context, email, phone, password string, partnerID int64) (*model.Customer, error)
+	// Exists indica si el email o el teléfono ya tienen una cuenta que no esté abandonada.
+	Exists(ctx context.Context, email, phone string) (bool, error)
+	// Authenticate busca la cuenta por email (si login contiene @) o por teléfono.
+	Authenticate(ctx context.Context, login, password string) (*model.Customer, error)
+	Get(ctx context.Context, id int64) (*model.Customer, error)
+	SetPhone(ctx context.Context, id int64, phone string) error
+	// SetPartner enlaza la cuenta a otro contacto de Odoo.
+	SetPartner(ctx context.Context, id, partnerID int64) error
+	// CreateToken genera un token de un solo uso; solo se guarda su hash.
+	CreateToken(ctx context.Context, id int64, purpose string, ttl time.Duration) (string, error)
+	// VerifyEmail consume un token TokenVerifyEmail y marca el email de su cuenta como verificado.
+	VerifyEmail(ctx context.Context, token string) (int64, error)
+}
+
+type sqlCustomerRepo struct {
+	db         *sql.DB
+	timeout    time.Duration
+	unverified time.Duration
+}
+
+// NewCustomerRepo construye el repositorio de cuentas; timeout limita cada consulta (0 sin límite).
+// Una cuenta sin verificar, creada hace más de unverified y sin enlaces de verificación
+// vigentes, está abandonada: un alta nueva con su email o su teléfono la reemplaza, para
+// que quien escribió datos ajenos no los bloquee para siempre.
+func NewCustomerRepo(db *sql.DB, timeout, unverified time.Duration) CustomerRepo {
+	return &sqlCustomerRepo{db: db, timeout: timeout, unverified: unverified}
+}
+
+// abandonedAccount selecciona las cuentas abandonadas; $3 es unverified en segundos.
+const abandonedAccount = `a.email_verified_at IS NULL AND a.created_at < now() - $3 * interval '1 second'
+	AND NOT EXISTS (SELECT 1 FROM customer_token t WHERE t.account_id = a.id AND t.expires_at > now())`
+
+// dummyHash se compara cuando la cuenta no existe, para que el tiempo de respuesta no
+// revele qué emails están registrados.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+const customerColumns = "id, email, COALESCE(phone, ''), password, partner_id, email_verified_at IS NOT NULL"
+
+func scanCustomer(row *sql.Row) (*model.Customer, error) {
+	var c model.Customer
+	if err := row.Scan(&c.ID, &c.Email, &c.Phone, &c.Password, &c.PartnerID, &c.EmailVerified); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// isUniqueViolation indica que el INSERT o UPDATE chocó con un índice único.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *sqlCustomerRepo) Create(ctx context.Context, email, phone, password string, partnerID int64) (*model.Customer, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating customer: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Sus tokens se borran en cascada; el contacto de Odoo se queda, puede tener pedidos.
+	replace := "DELETE FROM customer_account a WHERE (lower(a.email) = lower($1) OR a.phone = NULLIF($2, '')) AND " + abandonedAccount
+	if _, err := tx.ExecContext(ctx, replace, email, phone, int64(r.unverified.Seconds())); err != nil {
+		return nil, fmt.Errorf("error deleting abandoned customers: %w", err)
+	}
+	query := "INSERT INTO customer_account (email, phone, password, partner_id) VALUES ($1, NULLIF($2, ''), $3, $4) RETURNING " + customerColumns
+	c, err := scanCustomer(tx.QueryRowContext(ctx, query, email, phone, string(hashed), partnerID))
+	if isUniqueViolation(err) {
+		return nil, ErrCustomerExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating customer: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error creating customer: %w", err)
+	}
+	return c, nil
+}
+
+func (r *sqlCustomerRepo) Exists(ctx context.Context, email, phone string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM customer_account a WHERE (lower(a.email) = lower($1) OR a.phone = NULLIF($2, '')) AND NOT (" + abandonedAccount + "))"
+	if err := r.db.QueryRowContext(ctx, query, email, phone, int64(r.unverified.Seconds())).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error checking customer: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *sqlCustomerRepo) Authenticate(ctx context.Context, login, password string) (*model.Customer, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := "SELECT " + customerColumns + " FROM customer_account WHERE phone = $1"
+	if strings.Contains(login, "@") {
+		query = "SELECT " + customerColumns + " FROM customer_account WHERE lower(email) = lower($1)"
+	}
+	c, err := scanCustomer(r.db.QueryRowContext(ctx, query, login))
+	if err == sql.ErrNoRows {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving customer: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(c.Password), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return c, nil
+}
+
+func (r *sqlCustomerRepo) Get(ctx context.Context, id int64) (*model.Customer, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	c, err := scanCustomer(r.db.QueryRowContext(ctx, "SELECT "+customerColumns+" FROM customer_account WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving customer: %w", err)
+	}
+	return c, nil
+}
+
+func (r *sqlCustomerRepo) SetPhone(ctx context.Context, id int64, phone string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE customer_account SET phone = NULLIF($2, '') WHERE id = $1", id, phone)
+	if isUniqueViolation(err) {
+		return ErrCustomerExists
+	}
+	if err != nil {
+		return fmt.Errorf("error updating customer: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlCustomerRepo) SetPartner(ctx context.Context, id, partnerID int64) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, "UPDATE customer_account SET partner_id = $2 WHERE id = $1", id, partnerID); err != nil {
+		return fmt.Errorf("error updating customer partner: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlCustomerRepo) CreateToken(ctx context.Context, id int64, purpose string, ttl time.Duration) (string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	// Los tokens caducados de la cuenta se limpian al pedir uno nuevo.
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM customer_token WHERE account_id = $1 AND expires_at < now()", id); err != nil {
+		return "", fmt.Errorf("error deleting expired tokens: %w", err)
+	}
+	query := "INSERT INTO customer_token (token_hash, account_id, purpose, expires_at) VALUES ($1, $2, $3, now() + $4 * interval '1 second')"
+	if _, err := r.db.ExecContext(ctx, query, hashToken(token), id, purpose, int64(ttl.Seconds())); err != nil {
+		return "", fmt.Errorf("error creating token: %w", err)
+	}
+	return token, nil
+}
+
+func (r *sqlCustomerRepo) VerifyEmail(ctx context.Context, token string) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// El token se borra aunque haya caducado: en ningún caso vuelve a servir.
+	query := `WITH used AS (
+		DELETE FROM customer_token WHERE token_hash = $1 AND purpose = $2 RETURNING account_id, expires_at
+	)
+	UPDATE customer_account a SET email_verified_at = COALESCE(a.email_verified_at, now())
+	FROM used WHERE a.id = used.account_id AND used.expires_at > now()
+	RETURNING a.id`
+	var id int64
+	err := r.db.QueryRowContext(ctx, query, hashToken(token), TokenVerifyEmail).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidToken
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error verifying email: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrZoneNotFound indica que la zona de entrega no existe.
+	ErrZoneNotFound = errors.New("zona de entrega no encontrada")
+	// ErrZoneConflict indica que algún municipio ya pertenece a otra zona.
+	ErrZoneConflict = errors.New("algún municipio ya pertenece a otra zona")
+	// ErrUnknownMunicipality indica que algún municipio de la zona no existe en Odoo.
+	ErrUnknownMunicipality = errors.New("algún municipio no existe")
+)
+
+// DeliveryZoneRepo guarda las zonas de entrega a domicilio (cmd/internal/db/delivery.sql).
+type DeliveryZoneRepo interface {
+	List(ctx context.Context) ([]model.DeliveryZone, error)
+	Get(ctx context.Context, id int64) (*model.DeliveryZone, error)
+	Create(ctx context.Context, zone model.DeliveryZone) (*model.DeliveryZone, error)
+	// Update reemplaza la zona, municipios incluidos.
+	Update(ctx context.Context, zone model.DeliveryZone) (*model.DeliveryZone, error)
+	Delete(ctx context.Context, id int64) error
+	// ForMunicipality devuelve la zona activa del municipio, o nil si no tiene.
+	ForMunicipality(ctx context.Context, municipalityID int64) (*model.DeliveryZone, error)
+}
+
+type sqlDeliveryZoneRepo struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewDeliveryZoneRepo construye el repositorio de zonas. Escribe, así que db es el primario.
+func NewDeliveryZoneRepo(db *sql.DB, timeout time.Duration) DeliveryZoneRepo {
+	return &sqlDeliveryZoneRepo{db: db, timeout: timeout}
+}
+
+const zonesQuery = `SELECT z.id, z.name, z.fee, z.free_from, z.lead_time_days, z.active,
+		COALESCE(array_agg(m.municipality_id ORDER BY m.municipality_id) FILTER (WHERE m.municipality_id IS NOT NULL), '{}')
+	FROM delivery_zone z LEFT JOIN delivery_zone_municipality m ON m.zone_id = z.id`
+
+func (r *sqlDeliveryZoneRepo) query(ctx context.Context, where string, args ...any) ([]model.DeliveryZone, error) {
+	rows, err := r.db.QueryContext(ctx, zonesQuery+" "+where+" GROUP BY z.id ORDER BY z.name, z.id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving delivery zones: %w", err)
+	}
+	defer rows.Close()
+
+	zones := []model.DeliveryZone{}
+	for rows.Next() {
+		var (
+			zone     model.DeliveryZone
+			freeFrom sql.NullFloat64
+		)
+		if err := rows.Scan(&zone.ID, &zone.Name, &zone.Fee, &freeFrom, &zone.LeadTimeDays, &zone.Active, pq.Array(&zone.Municipalities)); err != nil {
+			return nil, fmt.Errorf("error retrieving delivery zones: %w", err)
+		}
+		if freeFrom.Valid {
+			zone.FreeFrom = &freeFrom.Float64
+		}
+		zones = append(zones, zone)
+	}
+	return zones, rows.Err()
+}
+
+func (r *sqlDeliveryZoneRepo) List(ctx context.Context) ([]model.DeliveryZone, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.query(ctx, "")
+}
+
+func (r *sqlDeliveryZoneRepo) Get(ctx context.Context, id int64) (*model.DeliveryZone, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.get(ctx, id)
+}
+
+func (r *sqlDeliveryZoneRepo) get(ctx context.Context, id int64) (*model.DeliveryZone, error) {
+	zones, err := r.query(ctx, "WHERE z.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(zones) == 0 {
+		return nil, ErrZoneNotFound
+	}
+	return &zones[0], nil
+}
+
+func (r *sqlDeliveryZoneRepo) Create(ctx context.Context, zone model.DeliveryZone) (*model.DeliveryZone, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating delivery zone: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO delivery_zone (name, fee, free_from, lead_time_days, active) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		zone.Name, zone.Fee, zone.FreeFrom, zone.LeadTimeDays, zone.Active).Scan(&zone.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating delivery zone: %w", err)
+	}
+	if err := setMunicipalities(ctx, tx, zone); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error creating delivery zone: %w", err)
+	}
+	return r.get(ctx, zone.ID)
+}
+
+func (r *sqlDeliveryZoneRepo) Update(ctx context.Context, zone model.DeliveryZone) (*model.DeliveryZone, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error updating delivery zone: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE delivery_zone SET name = $2, fee = $3, free_from = $4, lead_time_days = $5, active = $6 WHERE id = $1",
+		zone.ID, zone.Name, zone.Fee, zone.FreeFrom, zone.LeadTimeDays, zone.Active)
+	if err != nil {
+		return nil, fmt.Errorf("error updating delivery zone: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("error updating delivery zone: %w", err)
+	} else if n == 0 {
+		return nil, ErrZoneNotFound
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM delivery_zone_municipality WHERE zone_id = $1", zone.ID); err != nil {
+		return nil, fmt.Errorf("error updating delivery zone: %w", err)
+	}
+	if err := setMunicipalities(ctx, tx, zone); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error updating delivery zone: %w", err)
+	}
+	return r.get(ctx, zone.ID)
+}
+
+// setMunicipalities asigna a la zona los municipios que existen en Odoo; si falta alguno
+// devuelve ErrUnknownMunicipality y si alguno es de otra zona ErrZoneConflict.
+func setMunicipalities(ctx context.Context, tx *sql.Tx, zone model.DeliveryZone) error {
+	if len(zone.Municipalities) == 0 {
+		return nil
+	}
+	res, err := tx.ExecContext(ctx, "INSERT INTO delivery_zone_municipality (municipality_id, zone_id) SELECT m.id, $2 FROM "+municipalityTable+" m WHERE m.id = ANY($1)",
+		pq.Array(zone.Municipalities), zone.ID)
+	if isUniqueViolation(err) {
+		return ErrZoneConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error saving zone municipalities: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error saving zone municipalities: %w", err)
+	}
+	if int(n) != len(zone.Municipalities) {
+		return ErrUnknownMunicipality
+	}
+	return nil
+}
+
+func (r *sqlDeliveryZoneRepo) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM delivery_zone WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting delivery zone: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error deleting delivery zone: %w", err)
+	}
+	if n == 0 {
+		return ErrZoneNotFound
+	}
+	return nil
+}
+
+func (r *sqlDeliveryZoneRepo) ForMunicipality(ctx context.Context, municipalityID int64) (*model.DeliveryZone, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	zones, err := r.query(ctx, "WHERE z.active AND z.id = (SELECT zone_id FROM delivery_zone_municipality WHERE municipality_id = $1)", municipalityID)
+	if err != nil || len(zones) == 0 {
+		return nil, err
+	}
+	return &zones[0], nil
+}
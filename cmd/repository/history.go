@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// ErrOrderNotFound indica que el pedido no existe o no es del cliente.
+var ErrOrderNotFound = errors.New("pedido no encontrado")
+
+// OrderHistoryRepo lee los pedidos de un contacto: los de la web y la tienda online
+// (sale.order) y los del punto de venta (pos.order).
+type OrderHistoryRepo interface {
+	// List devuelve los pedidos del contacto del más reciente al más antiguo.
+	List(ctx context.Context, partnerID int64, page, pageSize int) (*model.CustomerOrders, error)
+	// Get busca el pedido por referencia entre los del contacto; ErrOrderNotFound si no es suyo.
+	Get(ctx context.Context, partnerID int64, ref string) (*model.CustomerOrder, error)
+}
+
+// historySource describe un modelo de pedidos de Odoo y sus líneas.
+type historySource struct {
+	source     string
+	model      string
+	lineModel  string
+	fields     []string
+	lineFields []string
+}
+
+var (
+	saleHistory = historySource{
+		source:     "online",
+		model:      "sale.order",
+		lineModel:  "sale.order.line",
+		fields:     []string{"name", "date_order", "state", "invoice_status", "amount_untaxed", "amount_tax", "amount_total", "currency_id", "picking_ids"},
+		lineFields: []string{"order_id", "product_id", "name", "product_uom_qty", "qty_delivered", "price_unit", "price_subtotal", "price_total"},
+	}
+	posHistory = historySource{
+		source:     "store",
+		model:      "pos.order",
+		lineModel:  "pos.order.line",
+		fields:     []string{"name", "date_order", "state", "account_move", "amount_tax", "amount_total", "currency_id", "picking_ids"},
+		lineFields: []string{"order_id", "product_id", "full_product_name", "qty", "price_unit", "price_subtotal", "price_subtotal_incl"},
+	}
+)
+
+type rpcHistoryOrder struct {
+	ID            int64            `json:"id"`
+	Name          odoorpc.String   `json:"name"`
+	DateOrder     odoorpc.Time     `json:"date_order"`
+	State         odoorpc.String   `json:"state"`
+	InvoiceStatus odoorpc.String   `json:"invoice_status"`
+	AccountMove   odoorpc.Many2one `json:"account_move"`
+	AmountUntaxed float64          `json:"amount_untaxed"`
+	AmountTax     float64          `json:"amount_tax"`
+	AmountTotal   float64          `json:"amount_total"`
+	Currency      odoorpc.Many2one `json:"currency_id"`
+	PickingIDs    []int64          `json:"picking_ids"`
+}
+
+// rpcHistoryLine junta los campos de sale.order.line y pos.order.line.
+type rpcHistoryLine struct {
+	Order             odoorpc.Many2one `json:"order_id"`
+	Product           odoorpc.Many2one `json:"product_id"`
+	Name              odoorpc.String   `json:"name"`
+	FullProductName   odoorpc.String   `json:"full_product_name"`
+	ProductUomQty     float64          `json:"product_uom_qty"`
+	Qty               float64          `json:"qty"`
+	QtyDelivered      float64          `json:"qty_delivered"`
+	PriceUnit         float64          `json:"price_unit"`
+	PriceSubtotal     float64          `json:"price_subtotal"`
+	PriceTotal        float64          `json:"price_total"`
+	PriceSubtotalIncl float64          `json:"price_subtotal_incl"`
+}
+
+type rpcPicking struct {
+	ID    int64          `json:"id"`
+	State odoorpc.String `json:"state"`
+}
+
+type rpcOrderHistoryRepo struct {
+	client  *odoorpc.Client
+	config  ProductRepoConfig
+	sources []historySource
+}
+
+// NewRPCOrderHistoryRepo construye el historial sobre un cliente de Odoo. Con pos a false
+// no se consulta pos.order (el módulo point_of_sale no está instalado).
+func NewRPCOrderHistoryRepo(client *odoorpc.Client, config ProductRepoConfig, pos bool) OrderHistoryRepo {
+	sources := []historySource{saleHistory}
+	if pos {
+		sources = append(sources, posHistory)
+	}
+	return &rpcOrderHistoryRepo{client: client, config: config, sources: sources}
+}
+
+// sourcedOrder es un pedido leído junto con el modelo del que viene.
+type sourcedOrder struct {
+	source *historySource
+	row    rpcHistoryOrder
+}
+
+func (r *rpcOrderHistoryRepo) List(ctx context.Context, partnerID int64, page, pageSize int) (*model.CustomerOrders, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	session := companyContext(r.config.forStore(ctx))
+
+	// Cada modelo aporta como mucho offset+pageSize pedidos; la página sale de mezclarlos por fecha.
+	offset := (page - 1) * pageSize
+	domain := odoorpc.Domain{odoorpc.Cond("partner_id", "=", partnerID)}
+	var total int
+	var orders []sourcedOrder
+	for i := range r.sources {
+		source := &r.sources[i]
+		n, err := r.client.SearchCount(ctx, source.model, domain, session)
+		if err != nil {
+			return nil, fmt.Errorf("error al contar los pedidos: %w", err)
+		}
+		total += n
+		if n == 0 {
+			continue
+		}
+		var rows []rpcHistoryOrder
+		opts := odoorpc.SearchOptions{Fields: source.fields, Limit: offset + pageSize, Order: "date_order desc, id desc", Context: session}
+		if err := r.client.SearchRead(ctx, source.model, domain, opts, &rows); err != nil {
+			return nil, fmt.Errorf("error al leer los pedidos: %w", err)
+		}
+		for _, row := range rows {
+			orders = append(orders, sourcedOrder{source: source, row: row})
+		}
+	}
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].row.DateOrder.After(orders[j].row.DateOrder.Time)
+	})
+	if offset >= len(orders) {
+		orders = nil
+	} else {
+		orders = orders[offset:min(offset+pageSize, len(orders))]
+	}
+
+	result, err := r.details(ctx, session, orders)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CustomerOrders{Orders: result, Total: uint(total)}, nil
+}
+
+func (r *rpcOrderHistoryRepo) Get(ctx context.Context, partnerID int64, ref string) (*model.CustomerOrder, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	session := companyContext(r.config.forStore(ctx))
+
+	domain := odoorpc.Domain{odoorpc.Cond("partner_id", "=", partnerID), odoorpc.Cond("name", "=", ref)}
+	for i := range r.sources {
+		source := &r.sources[i]
+		var rows []rpcHistoryOrder
+		if err := r.client.SearchRead(ctx, source.model, domain, odoorpc.SearchOptions{Fields: source.fields, Limit: 1, Context: session}, &rows); err != nil {
+			return nil, fmt.Errorf("error al leer el pedido: %w", err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		orders, err := r.details(ctx, session, []sourcedOrder{{source: source, row: rows[0]}})
+		if err != nil {
+			return nil, err
+		}
+		return &orders[0], nil
+	}
+	return nil, ErrOrderNotFound
+}
+
+// details completa los pedidos con sus líneas y el estado de sus entregas.
+func (r *rpcOrderHistoryRepo) details(ctx context.Context, session map[string]any, orders []sourcedOrder) ([]model.CustomerOrder, error) {
+	orderIDs := map[*historySource][]int64{}
+	var pickingIDs []int64
+	for _, order := range orders {
+		orderIDs[order.source] = append(orderIDs[order.source], order.row.ID)
+		pickingIDs = append(pickingIDs, order.row.PickingIDs...)
+	}
+
+	lines := map[*historySource]map[int64][]model.CustomerOrderLine{}
+	for source, ids := range orderIDs {
+		domain := odoorpc.Domain{odoorpc.Cond("order_id", "in", ids)}
+		if source.lineModel == "sale.order.line" {
+			// Las secciones y notas del presupuesto no son productos.
+			domain = append(domain, odoorpc.Cond("display_type", "=", false))
+		}
+		var rows []rpcHistoryLine
+		if err := r.client.SearchRead(ctx, source.lineModel, domain, odoorpc.SearchOptions{Fields: source.lineFields, Order: "id", Context: session}, &rows); err != nil {
+			return nil, fmt.Errorf("error al leer las líneas de los pedidos: %w", err)
+		}
+		byOrder := map[int64][]model.CustomerOrderLine{}
+		for _, row := range rows {
+			byOrder[row.Order.ID] = append(byOrder[row.Order.ID], row.line())
+		}
+		lines[source] = byOrder
+	}
+
+	pickings := map[int64]string{}
+	if len(pickingIDs) > 0 {
+		var rows []rpcPicking
+		domain := odoorpc.Domain{odoorpc.Cond("id", "in", pickingIDs), odoorpc.Cond("picking_type_code", "=", "outgoing")}
+		if err := r.client.SearchRead(ctx, "stock.picking", domain, odoorpc.SearchOptions{Fields: []string{"state"}, Context: session}, &rows); err != nil {
+			return nil, fmt.Errorf("error al leer las entregas: %w", err)
+		}
+		for _, row := range rows {
+			pickings[row.ID] = string(row.State)
+		}
+	}
+
+	result := make([]model.CustomerOrder, len(orders))
+	for i, order := range orders {
+		row := order.row
+		result[i] = model.CustomerOrder{
+			Reference:      string(row.Name),
+			Source:         order.source.source,
+			Date:           row.DateOrder.Ptr(),
+			State:          string(row.State),
+			InvoiceStatus:  string(row.InvoiceStatus),
+			DeliveryStatus: deliveryStatus(row.PickingIDs, pickings),
+			AmountUntaxed:  row.AmountUntaxed,
+			AmountTax:      row.AmountTax,
+			AmountTotal:    row.AmountTotal,
+			Currency:       row.Currency.Name,
+			Lines:          lines[order.source][row.ID],
+		}
+		if order.source.model == "pos.order" {
+			// pos.order no tiene invoice_status ni amount_untaxed.
+			result[i].AmountUntaxed = row.AmountTotal - row.AmountTax
+			result[i].InvoiceStatus = "no"
+			if row.State == "invoiced" || row.AccountMove.ID != 0 {
+				result[i].InvoiceStatus = "invoiced"
+			}
+		}
+		if result[i].Lines == nil {
+			result[i].Lines = []model.CustomerOrderLine{}
+		}
+	}
+	return result, nil
+}
+
+func (l rpcHistoryLine) line() model.CustomerOrderLine {
+	line := model.CustomerOrderLine{
+		ProductID:         l.Product.ID,
+		Name:              string(l.Name),
+		Quantity:          l.ProductUomQty,
+		QuantityDelivered: l.QtyDelivered,
+		PriceUnit:         l.PriceUnit,
+		Subtotal:          l.PriceSubtotal,
+		Total:             l.PriceTotal,
+	}
+	// Línea del punto de venta: se entrega en el momento.
+	if l.Qty != 0 {
+		line.Name = string(l.FullProductName)
+		line.Quantity, line.QuantityDelivered = l.Qty, l.Qty
+		line.Total = l.PriceSubtotalIncl
+	}
+	if line.Name == "" {
+		line.Name = l.Product.Name
+	}
+	return line
+}
+
+// deliveryStatus resume los albaranes de salida del pedido: "" si no tiene, "delivered" si
+// todos están hechos, "partial" si solo algunos y "pending" si ninguno. Los cancelados no cuentan.
+func deliveryStatus(ids []int64, pickings map[int64]string) string {
+	var done, pending int
+	for _, id := range ids {
+		switch pickings[id] {
+		case "":
+			// No es de salida.
+		case "cancel":
+		case "done":
+			done++
+		default:
+			pending++
+		}
+	}
+	switch {
+	case done == 0 && pending == 0:
+		return ""
+	case pending == 0:
+		return "delivered"
+	case done == 0:
+		return "pending"
+	}
+	return "partial"
+}
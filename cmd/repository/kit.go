@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// catalogLocationID es la ubicación de stock (stock_location) que alimenta el catálogo
+// cuando ni la configuración ni la tienda indican otra.
+const catalogLocationID = 8
+
+// phantomBoms elige, para cada variante, la primera lista de materiales tipo kit
+// (mrp_bom.type = 'phantom') por secuencia, ya sea propia de la variante o de su plantilla.
+const phantomBoms = `SELECT DISTINCT ON (kp.id) kp.id AS product_id, b.id AS bom_id, b.product_qty AS bom_qty
+	FROM mrp_bom b
+	INNER JOIN product_product kp ON kp.id = b.product_id OR (b.product_id IS NULL AND kp.product_tmpl_id = b.product_tmpl_id)
+	WHERE b.type = 'phantom' AND b.active
+	ORDER BY kp.id, b.product_id IS NULL, b.sequence, b.id`
+
+// stockSource devuelve un subquery con la forma de stock_quant (product_id, location_id, quantity)
+// que añade a los quants reales una fila por kit con la cantidad que se puede armar en la
+// ubicación del catálogo: el mínimo sobre los componentes de floor(stock / cantidad por kit).
+// Con config.Holds resta además las reservas vigentes (stock_hold) como filas negativas.
+func stockSource(config ProductRepoConfig) string {
+	location := config.location()
+	source := fmt.Sprintf(`(SELECT product_id, location_id, quantity FROM stock_quant
+	UNION ALL
+	SELECT kit.product_id, %d AS location_id, MIN(FLOOR(COALESCE(cs.qty, 0) / (bl.product_qty / NULLIF(kit.bom_qty, 0)))) AS quantity
+	FROM (%s) kit
+	INNER JOIN mrp_bom_line bl ON bl.bom_id = kit.bom_id AND bl.product_qty > 0
+	LEFT JOIN (SELECT product_id, SUM(quantity) AS qty FROM stock_quant WHERE location_id = %d GROUP BY product_id) cs ON cs.product_id = bl.product_id
+	GROUP BY kit.product_id`, location, phantomBoms, location)
+	if config.Holds {
+		source += "\n\tUNION ALL\n\t" + activeHolds
+	}
+	return source + ")"
+}
+
+// stockByLocation es como stockSource pero para las ubicaciones del parámetro $2 (un array):
+// los kits se arman con los componentes de cada una y solo se leen quants de esas ubicaciones.
+func stockByLocation(config ProductRepoConfig) string {
+	source := `(SELECT product_id, location_id, quantity FROM stock_quant WHERE location_id = ANY($2)
+	UNION ALL
+	SELECT kit.product_id, loc.id AS location_id, MIN(FLOOR(COALESCE(cs.qty, 0) / (bl.product_qty / NULLIF(kit.bom_qty, 0)))) AS quantity
+	FROM (` + phantomBoms + `) kit
+	INNER JOIN mrp_bom_line bl ON bl.bom_id = kit.bom_id AND bl.product_qty > 0
+	CROSS JOIN unnest($2::int[]) AS loc(id)
+	LEFT JOIN (SELECT product_id, location_id, SUM(quantity) AS qty FROM stock_quant WHERE location_id = ANY($2) GROUP BY product_id, location_id) cs
+		ON cs.product_id = bl.product_id AND cs.location_id = loc.id
+	GROUP BY kit.product_id, loc.id`
+	if config.Holds {
+		source += "\n\tUNION ALL\n\t" + activeHolds
+	}
+	return source + ")"
+}
+
+// getKitComponents devuelve los componentes del kit del producto con su stock en location,
+// o nil si no es un kit.
+func (r *odooProductRepo) getKitComponents(ctx context.Context, productID, location int64) ([]model.KitComponent, error) {
+	query := fmt.Sprintf(`SELECT cp.id, %s, bl.product_qty / NULLIF(kit.bom_qty, 0), COALESCE(cs.qty, 0)
+	FROM (%s) kit
+	INNER JOIN mrp_bom_line bl ON bl.bom_id = kit.bom_id
+	INNER JOIN product_product cp ON cp.id = bl.product_id
+	INNER JOIN product_template cpt ON cpt.id = cp.product_tmpl_id
+	LEFT JOIN (SELECT product_id, SUM(quantity) AS qty FROM stock_quant WHERE location_id = %d GROUP BY product_id) cs ON cs.product_id = cp.id
+	WHERE kit.product_id = $1
+	ORDER BY bl.sequence, bl.id;`, r.schema.name("cpt", "product.template"), phantomBoms, location)
+
+	rows, err := r.DB.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los componentes del kit: %w", err)
+	}
+	defer rows.Close()
+
+	var components []model.KitComponent
+	for rows.Next() {
+		var (
+			component model.KitComponent
+			name      string
+		)
+		if err := rows.Scan(&component.ProductID, &name, &component.Quantity, &component.Stock); err != nil {
+			log.Printf("error al leer el componente: %v", err)
+			continue
+		}
+		if component.Name, err = getValueJson(name, ""); err != nil {
+			log.Printf("Error en name: %v", err)
+		}
+		components = append(components, component)
+	}
+	return components, rows.Err()
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/query"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// OrderRepo crea los pedidos de la tienda como presupuestos (sale.order en borrador) de Odoo.
+type OrderRepo interface {
+	// FindByKey devuelve el pedido creado con la clave de idempotencia, o nil si no hay ninguno.
+	// Si la clave se usó con otra petición devuelve ErrIdempotencyMismatch.
+	FindByKey(ctx context.Context, key string, order model.OrderRequest) (*model.OrderResult, error)
+	// Create valida las líneas contra el precio y el stock actuales y crea el presupuesto.
+	// Si alguna línea no se puede pedir devuelve un *OrderRejectedError.
+	Create(ctx context.Context, key string, order model.OrderRequest) (*model.OrderResult, error)
+}
+
+// ErrIdempotencyMismatch indica que la clave de idempotencia ya se usó con otro carrito o
+// con otro cliente; no se devuelve el pedido de esa otra petición.
+var ErrIdempotencyMismatch = errors.New("la clave de idempotencia ya se usó con otro pedido")
+
+// OrderRejectedError indica que el carrito no coincide con el catálogo actual.
+type OrderRejectedError struct {
+	Problems []model.OrderLineProblem
+}
+
+func (e *OrderRejectedError) Error() string {
+	return fmt.Sprintf("el pedido tiene %d líneas que no se pueden pedir", len(e.Problems))
+}
+
+// orderOriginPrefix marca en sale.order.origin los pedidos de la tienda, seguido de la
+// clave de idempotencia, "#" y la huella de la petición (orderFingerprint); así un
+// reintento encuentra el pedido aunque el backend se reinicie.
+const orderOriginPrefix = "web:"
+
+// fingerprintLength es la longitud en hexadecimal de la huella guardada en origin.
+const fingerprintLength = 16
+
+// orderFingerprint resume la petición (líneas, cliente y nota) para comprobar que un
+// reintento con la misma clave es el mismo pedido.
+func orderFingerprint(order model.OrderRequest) string {
+	order.Customer.Email = strings.ToLower(strings.TrimSpace(order.Customer.Email))
+	content, _ := json.Marshal(order)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:fingerprintLength]
+}
+
+// orderOrigin es el valor de sale.order.origin de un pedido de la tienda.
+func orderOrigin(key string, order model.OrderRequest) string {
+	return orderOriginPrefix + key + "#" + orderFingerprint(order)
+}
+
+// rpcOrderRepo implementa OrderRepo con la API JSON-RPC de Odoo. Los precios y el stock
+// se calculan igual que en rpcProductRepo.
+type rpcOrderRepo struct {
+	client   *odoorpc.Client
+	config   ProductRepoConfig
+	catalog  *rpcProductRepo
+	partners *rpcPartnerRepo
+	holds    HeldStock
+}
+
+// NewRPCOrderRepo construye el repositorio de pedidos sobre un cliente de Odoo. Con holds
+// (las reservas para recoger en tienda) el stock retenido no se puede pedir; puede ser nil.
+func NewRPCOrderRepo(client *odoorpc.Client, config ProductRepoConfig, holds HeldStock) OrderRepo {
+	return &rpcOrderRepo{
+		client:   client,
+		config:   config,
+		catalog:  &rpcProductRepo{client: client, config: config, schema: schemaFor(config.OdooVersion)},
+		partners: &rpcPartnerRepo{client: client, config: config},
+		holds:    holds,
+	}
+}
+
+// companyContext limita la sesión de Odoo a la compañía de la tienda, si tiene una.
+func companyContext(config ProductRepoConfig) map[string]any {
+	if id := config.Visibility.CompanyID; id > 0 {
+		return map[string]any{"allowed_company_ids": []int64{id}}
+	}
+	return nil
+}
+
+var orderFields = []string{"name", "state", "amount_total", "currency_id"}
+
+type rpcOrder struct {
+	ID          int64            `json:"id"`
+	Name        odoorpc.String   `json:"name"`
+	Origin      odoorpc.String   `json:"origin"`
+	State       odoorpc.String   `json:"state"`
+	AmountTotal float64          `json:"amount_total"`
+	Currency    odoorpc.Many2one `json:"currency_id"`
+}
+
+func (o rpcOrder) result() *model.OrderResult {
+	return &model.OrderResult{ID: o.ID, Reference: string(o.Name), State: string(o.State), AmountTotal: o.AmountTotal, Currency: o.Currency.Name}
+}
+
+func (r *rpcOrderRepo) FindByKey(ctx context.Context, key string, order model.OrderRequest) (*model.OrderResult, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	// La clave va escapada y la huella son exactamente fingerprintLength caracteres, así que
+	// el patrón solo encaja con esta clave, sea cual sea la petición con que se usó.
+	var orders []rpcOrder
+	pattern := orderOriginPrefix + query.EscapeLike(key) + "#" + strings.Repeat("_", fingerprintLength)
+	domain := odoorpc.Domain{odoorpc.Cond("origin", "=like", pattern)}
+	fields := append([]string{"origin"}, orderFields...)
+	if err := r.client.SearchRead(ctx, "sale.order", domain, odoorpc.SearchOptions{Fields: fields, Limit: 1, Context: companyContext(r.config.forStore(ctx))}, &orders); err != nil {
+		return nil, fmt.Errorf("error al buscar el pedido: %w", err)
+	}
+	if len(orders) == 0 {
+		return nil, nil
+	}
+	if string(orders[0].Origin) != orderOrigin(key, order) {
+		return nil, ErrIdempotencyMismatch
+	}
+	return orders[0].result(), nil
+}
+
+func (r *rpcOrderRepo) Create(ctx context.Context, key string, order model.OrderRequest) (*model.OrderResult, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	prices, err := r.check(ctx, config, order.Lines)
+	if err != nil {
+		return nil, err
+	}
+	customer := order.Customer
+	address := model.CustomerAddress{Name: customer.Name, Phone: customer.Phone, Street: customer.Street, City: customer.City}
+	// El pedido anónimo solo se asocia a un contacto con el mismo email exacto; si no hay
+	// ninguno se crea. El teléfono no sirve para buscarlo: cualquiera puede escribir uno ajeno.
+	// Si ya existe, los datos enviados van a una dirección de entrega nueva bajo ese contacto:
+	// ni se pierden ni se usa la dirección que tenga guardada.
+	partnerID, err := r.partners.FindByEmail(ctx, customer.Email)
+	if err != nil {
+		return nil, err
+	}
+	shippingID := partnerID
+	if partnerID == 0 {
+		partnerID, err = r.partners.Create(ctx, customer.Email, address)
+		shippingID = partnerID
+	} else {
+		shippingID, err = r.partners.createDelivery(ctx, partnerID, customer.Email, address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// El precio unitario se fija al validado, para que el presupuesto sea lo que vio el cliente.
+	lines := make([]any, len(order.Lines))
+	for i, line := range order.Lines {
+		lines[i] = []any{0, 0, map[string]any{
+			"product_id":      line.ProductID,
+			"product_uom_qty": line.Quantity,
+			"price_unit":      prices[line.ProductID],
+		}}
+	}
+	values := map[string]any{
+		"partner_id":          partnerID,
+		"partner_shipping_id": shippingID,
+		"origin":              orderOrigin(key, order),
+		"order_line":          lines,
+	}
+	if config.PricelistID > 0 {
+		values["pricelist_id"] = config.PricelistID
+	}
+	if config.Visibility.CompanyID > 0 {
+		values["company_id"] = config.Visibility.CompanyID
+	}
+	if note := strings.TrimSpace(order.Note); note != "" {
+		values["note"] = note
+	}
+	id, err := r.client.Create(ctx, "sale.order", values, companyContext(config))
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el pedido: %w", err)
+	}
+
+	var created []rpcOrder
+	if err := r.client.Read(ctx, "sale.order", []int64{id}, orderFields, companyContext(config), &created); err != nil {
+		return nil, fmt.Errorf("error al leer el pedido: %w", err)
+	}
+	if len(created) == 0 {
+		return nil, fmt.Errorf("error al leer el pedido %d", id)
+	}
+	return created[0].result(), nil
+}
+
+// check compara las líneas con los productos visibles: existencia, stock en la ubicación
+// del catálogo menos lo reservado (sumando las líneas del mismo producto) y precio de la
+// tarifa para una unidad.
+// Devuelve el precio actual de cada producto.
+func (r *rpcOrderRepo) check(ctx context.Context, config ProductRepoConfig, lines []model.OrderLine) (map[int64]float64, error) {
+	quantities := make(map[int64]float64, len(lines))
+	ids := make([]int64, 0, len(lines))
+	for _, line := range lines {
+		if _, ok := quantities[line.ProductID]; !ok {
+			ids = append(ids, line.ProductID)
+		}
+		quantities[line.ProductID] += line.Quantity
+	}
+
+	var rows []rpcProduct
+	domain := append(visibility(config), odoorpc.Cond("id", "in", ids))
+	fields := []string{"name", "product_tmpl_id", "categ_id", "list_price", "qty_available"}
+	if err := r.client.SearchRead(ctx, "product.product", domain, odoorpc.SearchOptions{Fields: fields, Context: stockContext(config)}, &rows); err != nil {
+		return nil, fmt.Errorf("error al comprobar los productos: %w", err)
+	}
+	items, err := r.catalog.pricelistItems(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := r.catalog.categoryPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var held map[int64]float64
+	if r.holds != nil {
+		if held, err = r.holds.Held(ctx, config.location(), ids); err != nil {
+			return nil, err
+		}
+	}
+
+	products := make(map[int64]rpcProduct, len(rows))
+	for _, row := range rows {
+		row.QtyAvailable = max(row.QtyAvailable-held[row.ID], 0)
+		products[row.ID] = row
+	}
+	// Los mismos precios que muestra el catálogo (rpcProductRepo y pricelistPrices).
+	priced := rpcPrices(items, paths, rows)
+	prices := make(map[int64]float64, len(rows))
+	var problems []model.OrderLineProblem
+	for _, line := range lines {
+		product, ok := products[line.ProductID]
+		if !ok {
+			problems = append(problems, model.OrderLineProblem{ProductID: line.ProductID, Reason: "unavailable"})
+			continue
+		}
+		price := priced[line.ProductID].price
+		prices[line.ProductID] = price
+
+		switch {
+		case product.QtyAvailable < quantities[line.ProductID]:
+			problems = append(problems, model.OrderLineProblem{ProductID: line.ProductID, Reason: "insufficient_stock", Stock: product.QtyAvailable, Price: price})
+		case math.Abs(line.Price-price) >= 0.005:
+			problems = append(problems, model.OrderLineProblem{ProductID: line.ProductID, Reason: "price_changed", Stock: product.QtyAvailable, Price: price})
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &OrderRejectedError{Problems: problems}
+	}
+	return prices, nil
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc/odoorpctest"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// heldStock es un HeldStock fijo: el mismo retenido en cualquier ubicación.
+type heldStock map[int64]float64
+
+func (h heldStock) Held(ctx context.Context, location int64, productIDs []int64) (map[int64]float64, error) {
+	return h, nil
+}
+
+func newFakeOrderRepo(t *testing.T, holds HeldStock) (OrderRepo, *odoorpctest.FakeServer) {
+	t.Helper()
+	client, fake := newFakeOdoo(t)
+	return NewRPCOrderRepo(client, ProductRepoConfig{PricelistID: 1}, holds), fake
+}
+
+func testOrder(lines ...model.OrderLine) model.OrderRequest {
+	return model.OrderRequest{
+		Lines:    lines,
+		Customer: model.OrderCustomer{Name: "Ana", Email: "ana@example.com", Phone: "5550001"},
+	}
+}
+
+func TestRPCOrderRepoCreateAndReplay(t *testing.T) {
+	repo, fake := newFakeOrderRepo(t, nil)
+	ctx := context.Background()
+	// La crema hidratante tiene un 10% de descuento por categoría: 12.5 -> 11.25.
+	order := testOrder(model.OrderLine{ProductID: 1, Quantity: 2, Price: 11.25}, model.OrderLine{ProductID: 3, Quantity: 1, Price: 5})
+
+	found, err := repo.FindByKey(ctx, "key-1", order)
+	if err != nil || found != nil {
+		t.Fatalf("FindByKey before create = %v, %v; want nil, nil", found, err)
+	}
+	created, err := repo.Create(ctx, "key-1", order)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.Reference == "" || created.State != "draft" || created.AmountTotal != 27.5 {
+		t.Errorf("Create = %+v; want a draft order of 27.5", created)
+	}
+	lines := fake.Records("sale.order.line")
+	if len(lines) != 2 || lines[0]["price_unit"] != 11.25 {
+		t.Errorf("order lines = %v; want 2 lines priced from the pricelist", lines)
+	}
+	if sale := fake.Records("sale.order")[0]; sale["partner_shipping_id"] != sale["partner_id"] {
+		t.Errorf("sale.order shipping = %v; want the new partner %v", sale["partner_shipping_id"], sale["partner_id"])
+	}
+
+	replay, err := repo.FindByKey(ctx, "key-1", order)
+	if err != nil || replay == nil || replay.ID != created.ID {
+		t.Fatalf("FindByKey replay = %+v, %v; want order %d", replay, err, created.ID)
+	}
+
+	other := testOrder(model.OrderLine{ProductID: 1, Quantity: 3, Price: 11.25})
+	if _, err := repo.FindByKey(ctx, "key-1", other); !errors.Is(err, ErrIdempotencyMismatch) {
+		t.Errorf("FindByKey with another cart err = %v; want ErrIdempotencyMismatch", err)
+	}
+	stranger := order
+	stranger.Customer.Email = "eve@example.com"
+	if _, err := repo.FindByKey(ctx, "key-1", stranger); !errors.Is(err, ErrIdempotencyMismatch) {
+		t.Errorf("FindByKey with another customer err = %v; want ErrIdempotencyMismatch", err)
+	}
+	// Los comodines de la clave no encuentran pedidos de otras claves.
+	if found, err := repo.FindByKey(ctx, "key-%", order); err != nil || found != nil {
+		t.Errorf("FindByKey(key-%%) = %v, %v; want nil, nil", found, err)
+	}
+	if n := len(fake.Records("sale.order")); n != 1 {
+		t.Errorf("sale orders = %d; want 1", n)
+	}
+}
+
+func TestRPCOrderRepoRejectsLines(t *testing.T) {
+	repo, fake := newFakeOrderRepo(t, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		line   model.OrderLine
+		reason string
+		stock  float64
+		price  float64
+	}{
+		{"price changed", model.OrderLine{ProductID: 2, Quantity: 1, Price: 18}, "price_changed", 5, 16.2},
+		{"insufficient stock", model.OrderLine{ProductID: 2, Quantity: 6, Price: 16.2}, "insufficient_stock", 5, 16.2},
+		{"unavailable", model.OrderLine{ProductID: 99, Quantity: 1, Price: 1}, "unavailable", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.Create(ctx, "key-"+tt.reason, testOrder(tt.line))
+			var rejected *OrderRejectedError
+			if !errors.As(err, &rejected) {
+				t.Fatalf("Create err = %v; want *OrderRejectedError", err)
+			}
+			want := model.OrderLineProblem{ProductID: tt.line.ProductID, Reason: tt.reason, Stock: tt.stock, Price: tt.price}
+			if len(rejected.Problems) != 1 || rejected.Problems[0] != want {
+				t.Errorf("problems = %+v; want [%+v]", rejected.Problems, want)
+			}
+		})
+	}
+	if n := len(fake.Records("sale.order")); n != 0 {
+		t.Errorf("sale orders = %d; want none after rejected carts", n)
+	}
+}
+
+func TestRPCOrderRepoSubtractsHolds(t *testing.T) {
+	// Hay 5 cremas solares y 3 están reservadas para recoger en tienda.
+	repo, fake := newFakeOrderRepo(t, heldStock{2: 3})
+	ctx := context.Background()
+
+	_, err := repo.Create(ctx, "key-held", testOrder(model.OrderLine{ProductID: 2, Quantity: 3, Price: 16.2}))
+	var rejected *OrderRejectedError
+	if !errors.As(err, &rejected) {
+		t.Fatalf("Create err = %v; want *OrderRejectedError", err)
+	}
+	want := model.OrderLineProblem{ProductID: 2, Reason: "insufficient_stock", Stock: 2, Price: 16.2}
+	if len(rejected.Problems) != 1 || rejected.Problems[0] != want {
+		t.Errorf("problems = %+v; want [%+v]", rejected.Problems, want)
+	}
+
+	if _, err := repo.Create(ctx, "key-free", testOrder(model.OrderLine{ProductID: 2, Quantity: 2, Price: 16.2})); err != nil {
+		t.Fatalf("Create with the free stock: %v", err)
+	}
+	if n := len(fake.Records("sale.order")); n != 1 {
+		t.Errorf("sale orders = %d; want 1", n)
+	}
+}
+
+func TestRPCOrderRepoAcceptsCatalogPrices(t *testing.T) {
+	client, fake := newFakeOdoo(t)
+	config := ProductRepoConfig{PricelistID: 1, Visibility: VisibilityPolicy{Active: true, SaleOk: true}}
+	products := NewRPCProductRepo(client, config)
+	orders := NewRPCOrderRepo(client, config, nil)
+	ctx := context.Background()
+
+	// El carrito se arma con el precio que devuelve la ficha del producto.
+	var lines []model.OrderLine
+	for _, id := range []int64{1, 2, 3} {
+		product, err := products.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID(%d): %v", id, err)
+		}
+		lines = append(lines, model.OrderLine{ProductID: id, Quantity: 1, Price: product.Price})
+	}
+	if _, err := orders.Create(ctx, "key-catalog", testOrder(lines...)); err != nil {
+		t.Fatalf("Create with the catalog prices: %v", err)
+	}
+	if n := len(fake.Records("sale.order")); n != 1 {
+		t.Errorf("sale orders = %d; want 1", n)
+	}
+}
+
+func TestRPCOrderRepoShipsToSubmittedAddress(t *testing.T) {
+	repo, fake := newFakeOrderRepo(t, nil)
+	ctx := context.Background()
+	fake.Add("res.partner", odoorpctest.Record{"id": 40, "name": "Ana Pérez", "email": "ANA@example.com", "street": "Calle 1", "city": "Holguín"})
+
+	order := testOrder(model.OrderLine{ProductID: 3, Quantity: 1, Price: 5})
+	order.Customer.Street, order.Customer.City = "Calle 23", "La Habana"
+	if _, err := repo.Create(ctx, "key-ship", order); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// El contacto existente no cambia y el pedido va a una dirección de entrega suya.
+	var existing, delivery odoorpctest.Record
+	for _, p := range fake.Records("res.partner") {
+		switch {
+		case p["id"] == int64(40):
+			existing = p
+		case p["parent_id"] == int64(40):
+			delivery = p
+		}
+	}
+	if existing["street"] != "Calle 1" || existing["name"] != "Ana Pérez" {
+		t.Errorf("existing partner = %v; want it untouched", existing)
+	}
+	if delivery == nil || delivery["type"] != "delivery" || delivery["name"] != "Ana" || delivery["phone"] != "5550001" || delivery["street"] != "Calle 23" || delivery["city"] != "La Habana" {
+		t.Fatalf("delivery contact = %v; want the submitted name, phone and address", delivery)
+	}
+	sale := fake.Records("sale.order")[0]
+	if sale["partner_id"] != int64(40) || sale["partner_shipping_id"] != delivery["id"] {
+		t.Errorf("sale.order partner = %v, shipping = %v; want 40 and %v", sale["partner_id"], sale["partner_shipping_id"], delivery["id"])
+	}
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/query"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// PartnerRepo busca, crea y actualiza los contactos (res.partner) de los clientes en Odoo.
+type PartnerRepo interface {
+	// FindByEmail devuelve el contacto más antiguo con ese email exacto (sin distinguir
+	// mayúsculas), o 0 si no hay ninguno. Nunca se busca por teléfono: no prueba identidad.
+	FindByEmail(ctx context.Context, email string) (int64, error)
+	// Create crea un contacto nuevo con el email y los datos de address.
+	Create(ctx context.Context, email string, address model.CustomerAddress) (int64, error)
+	// Get devuelve la dirección del contacto.
+	Get(ctx context.Context, id int64) (*model.CustomerAddress, error)
+	// Update escribe la dirección en el contacto; los campos vacíos se borran.
+	Update(ctx context.Context, id int64, address model.CustomerAddress) error
+	// Delete borra un contacto recién creado que no llegó a usarse.
+	Delete(ctx context.Context, id int64) error
+}
+
+type rpcPartnerRepo struct {
+	client *odoorpc.Client
+	config ProductRepoConfig
+}
+
+// NewRPCPartnerRepo construye el repositorio de contactos sobre un cliente de Odoo.
+func NewRPCPartnerRepo(client *odoorpc.Client, config ProductRepoConfig) PartnerRepo {
+	return &rpcPartnerRepo{client: client, config: config}
+}
+
+var partnerFields = []string{"name", "phone", "street", "street2", "city", "zip"}
+
+type rpcPartner struct {
+	ID      int64          `json:"id"`
+	Name    odoorpc.String `json:"name"`
+	Phone   odoorpc.String `json:"phone"`
+	Street  odoorpc.String `json:"street"`
+	Street2 odoorpc.String `json:"street2"`
+	City    odoorpc.String `json:"city"`
+	Zip     odoorpc.String `json:"zip"`
+}
+
+// addressValues traduce la dirección a valores de res.partner; con clear los campos vacíos
+// se escriben como false para borrarlos, si no se omiten.
+func addressValues(address model.CustomerAddress, clear bool) map[string]any {
+	values := map[string]any{}
+	for field, value := range map[string]string{
+		"phone":   address.Phone,
+		"street":  address.Street,
+		"street2": address.Street2,
+		"city":    address.City,
+		"zip":     address.Zip,
+	} {
+		if value = strings.TrimSpace(value); value != "" {
+			values[field] = value
+		} else if clear {
+			values[field] = false
+		}
+	}
+	// El nombre es obligatorio en Odoo: nunca se borra.
+	if name := strings.TrimSpace(address.Name); name != "" {
+		values["name"] = name
+	}
+	return values
+}
+
+// emailCond compara el email del contacto con email literal y sin distinguir mayúsculas:
+// =ilike sin escapar trataría % y _ como comodines.
+func emailCond(field, email string) []any {
+	return odoorpc.Cond(field, "=ilike", query.EscapeLike(strings.TrimSpace(email)))
+}
+
+func (r *rpcPartnerRepo) FindByEmail(ctx context.Context, email string) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	if strings.TrimSpace(email) == "" {
+		return 0, nil
+	}
+	var partners []struct {
+		ID int64 `json:"id"`
+	}
+	opts := odoorpc.SearchOptions{Fields: []string{"id"}, Limit: 1, Order: "id"}
+	if err := r.client.SearchRead(ctx, "res.partner", odoorpc.Domain{emailCond("email", email)}, opts, &partners); err != nil {
+		return 0, fmt.Errorf("error al buscar el cliente: %w", err)
+	}
+	if len(partners) == 0 {
+		return 0, nil
+	}
+	return partners[0].ID, nil
+}
+
+func (r *rpcPartnerRepo) Create(ctx context.Context, email string, address model.CustomerAddress) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	values := addressValues(address, false)
+	values["email"] = strings.TrimSpace(email)
+	id, err := r.client.Create(ctx, "res.partner", values, companyContext(config))
+	if err != nil {
+		return 0, fmt.Errorf("error al crear el cliente: %w", err)
+	}
+	return id, nil
+}
+
+// createDelivery crea bajo el contacto parentID una dirección de entrega (type delivery)
+// con los datos de address, sin tocar los del contacto.
+func (r *rpcPartnerRepo) createDelivery(ctx context.Context, parentID int64, email string, address model.CustomerAddress) (int64, error) {
+	config := r.config.forStore(ctx)
+
+	values := addressValues(address, false)
+	values["parent_id"] = parentID
+	values["type"] = "delivery"
+	values["email"] = strings.TrimSpace(email)
+	id, err := r.client.Create(ctx, "res.partner", values, companyContext(config))
+	if err != nil {
+		return 0, fmt.Errorf("error al crear la dirección de entrega: %w", err)
+	}
+	return id, nil
+}
+
+func (r *rpcPartnerRepo) Get(ctx context.Context, id int64) (*model.CustomerAddress, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	var partners []rpcPartner
+	if err := r.client.Read(ctx, "res.partner", []int64{id}, partnerFields, nil, &partners); err != nil {
+		return nil, fmt.Errorf("error al leer el cliente: %w", err)
+	}
+	if len(partners) == 0 {
+		return nil, fmt.Errorf("el cliente %d no existe en Odoo", id)
+	}
+	p := partners[0]
+	return &model.CustomerAddress{
+		Name:    string(p.Name),
+		Phone:   string(p.Phone),
+		Street:  string(p.Street),
+		Street2: string(p.Street2),
+		City:    string(p.City),
+		Zip:     string(p.Zip),
+	}, nil
+}
+
+func (r *rpcPartnerRepo) Update(ctx context.Context, id int64, address model.CustomerAddress) error {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	if err := r.client.Write(ctx, "res.partner", []int64{id}, addressValues(address, true), nil); err != nil {
+		return fmt.Errorf("error al actualizar el cliente: %w", err)
+	}
+	return nil
+}
+
+func (r *rpcPartnerRepo) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	if err := r.client.Unlink(ctx, "res.partner", []int64{id}); err != nil {
+		return fmt.Errorf("error al borrar el cliente: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/db"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/lib/pq"
+)
+
+// pricelistItem elige la regla de product_pricelist_item de la tarifa $1 que aplica al
+// producto (alias pp, pt y pc) en el mismo orden que usa Odoo: variante, plantilla,
+// categoría (la más específica) y global. Se usa como subconsulta LATERAL con alias item.
+func pricelistItem(s schema) string {
+	return `SELECT i.compute_price, i.base, i.fixed_price, i.percent_price, i.price_discount, i.price_surcharge, ` + s.pricelistEnd() + ` AS date_end
+		FROM product_pricelist_item i
+		INNER JOIN product_pricelist pl ON pl.id = i.pricelist_id AND pl.active
+		LEFT JOIN product_category ic ON ic.id = i.categ_id
+		WHERE i.pricelist_id = $1
+			AND COALESCE(i.min_quantity, 0) <= 1
+			AND (i.date_start IS NULL OR i.date_start <= now())
+			AND (i.date_end IS NULL OR ` + s.pricelistEnd() + ` >= now())
+			AND (i.applied_on = '3_global'
+				OR (i.applied_on = '2_product_category' AND pc.parent_path LIKE ic.parent_path || '%')
+				OR (i.applied_on = '1_product' AND i.product_tmpl_id = pt.id)
+				OR (i.applied_on = '0_product_variant' AND i.product_id = pp.id))
+		ORDER BY i.applied_on, i.min_quantity DESC, ic.parent_path DESC NULLS LAST, i.id DESC
+		LIMIT 1`
+}
+
+// effectivePrice es el precio de venta según la regla item; sin regla es list_price.
+// Las fórmulas basadas en otra cosa que list_price (coste, otra tarifa) no se calculan.
+const effectivePrice = `CASE
+			WHEN item.compute_price = 'fixed' THEN item.fixed_price
+			WHEN item.compute_price = 'percentage' THEN pt.list_price * (1 - COALESCE(item.percent_price, 0) / 100)
+			WHEN item.base = 'list_price' THEN pt.list_price * (1 - COALESCE(item.price_discount, 0) / 100) + COALESCE(item.price_surcharge, 0)
+			ELSE pt.list_price
+		END`
+
+// PriceRepo da el precio de venta de los productos con la tarifa del catálogo, para lo que
+// se calcula en el backend a partir de un carrito (p. ej. el presupuesto de envío).
+type PriceRepo interface {
+	// Prices devuelve el precio de una unidad de cada producto visible, redondeado a
+	// céntimos; los que no existen o no son visibles no aparecen.
+	Prices(ctx context.Context, productIDs []int64) (map[int64]float64, error)
+}
+
+type odooPriceRepo struct {
+	DB     db.Reader
+	config ProductRepoConfig
+	schema schema
+}
+
+// NewPriceRepo construye el repositorio de precios sobre la base de Odoo.
+func NewPriceRepo(d db.Reader, config ProductRepoConfig) PriceRepo {
+	return &odooPriceRepo{DB: d, config: config, schema: schemaFor(config.OdooVersion)}
+}
+
+func (r *odooPriceRepo) Prices(ctx context.Context, productIDs []int64) (map[int64]float64, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	priced, err := pricelistPrices(ctx, r.DB, r.schema, r.config.forStore(ctx), productIDs)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[int64]float64, len(priced))
+	for id, p := range priced {
+		prices[id] = p.price
+	}
+	return prices, nil
+}
+
+// pricelistPrice es el precio de un producto en la tarifa y el fin de la regla, si tiene.
+type pricelistPrice struct {
+	price   float64
+	dateEnd *time.Time
+}
+
+// pricelistPrices calcula con effectivePrice el precio de una unidad, redondeado a céntimos,
+// de los productos visibles dados. Es el precio que muestra el catálogo y el que se exige
+// al crear un pedido.
+func pricelistPrices(ctx context.Context, reader db.Reader, s schema, config ProductRepoConfig, productIDs []int64) (map[int64]pricelistPrice, error) {
+	query := `SELECT pp.id, ` + effectivePrice + `, item.date_end
+	FROM product_product pp
+	INNER JOIN product_template pt ON pt.id = pp.product_tmpl_id
+	LEFT JOIN product_category pc ON pc.id = pt.categ_id
+	LEFT JOIN LATERAL (` + pricelistItem(s) + `) item ON true
+	WHERE pp.id = ANY($2) AND ` + config.Visibility.condition("pt", "pp")
+	rows, err := reader.QueryContext(ctx, query, config.PricelistID, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los precios: %w", err)
+	}
+	defer rows.Close()
+
+	prices := make(map[int64]pricelistPrice, len(productIDs))
+	for rows.Next() {
+		var (
+			id      int64
+			price   float64
+			dateEnd sql.NullTime
+		)
+		if err := rows.Scan(&id, &price, &dateEnd); err != nil {
+			return nil, fmt.Errorf("error al obtener los precios: %w", err)
+		}
+		p := pricelistPrice{price: math.Round(price*100) / 100}
+		if dateEnd.Valid {
+			p.dateEnd = &dateEnd.Time
+		}
+		prices[id] = p
+	}
+	return prices, rows.Err()
+}
+
+// applyPricelist pone en Price el precio de la tarifa (pricelistPrices) y, si es menor que
+// el de lista, el descuento y el fin de la promoción, igual que GetOnSale.
+func applyPricelist(products []model.ProductDTO, prices map[int64]pricelistPrice) {
+	for i := range products {
+		p, ok := prices[int64(products[i].ID)]
+		if !ok {
+			continue
+		}
+		products[i].Price = p.price
+		if original := products[i].OriginalPrice; original > 0 && p.price < original {
+			products[i].Discount = math.Round((1-p.price/original)*100*100) / 100
+			products[i].SaleEndDate = p.dateEnd
+		}
+	}
+}
+
+// attachPrices aplica a los productos los precios de la tarifa de la tienda.
+func (r *odooProductRepo) attachPrices(ctx context.Context, config ProductRepoConfig, products []model.ProductDTO) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(products))
+	for i, p := range products {
+		ids[i] = int64(p.ID)
+	}
+	prices, err := pricelistPrices(ctx, r.DB, r.schema, config, ids)
+	if err != nil {
+		return err
+	}
+	applyPricelist(products, prices)
+	return nil
+}
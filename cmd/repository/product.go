@@ -1,15 +1,21 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/db"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/query"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/lib/pq"
 )
 
 type Category struct {
@@ -19,31 +25,57 @@ type Category struct {
 
 // ProductRepo define la interfaz para acceso a productos en Odoo.
 type ProductRepo interface {
-	GetAll(offset, limit int) (*model.ProductsResult, error)
-	GetByID(id int64) (*model.ProductDTO, error)
-	GetFiltered(offset, limit int, categID, minPrice, maxPrice *int64, categorys []string, name, orderValue string) (*model.ProductsResult, error)
-	GetRelated(category, name string, offset, limit *int) (*model.ProductsResult, error)
-	GetBestSelling(limit int) ([]model.ProductDTO, error)
-	GetVariants(productID int64) ([]model.ProductDTO, error)
-	GetCategorys() ([]Category, error)
+	GetAll(ctx context.Context, offset, limit int) (*model.ProductsResult, error)
+	GetByID(ctx context.Context, id int64) (*model.ProductDTO, error)
+	GetFiltered(ctx context.Context, offset, limit int, categID, minPrice, maxPrice *int64, categorys []string, name, orderValue string, attributes map[string][]string) (*model.ProductsResult, error)
+	GetRelated(ctx context.Context, category, name string, offset, limit *int) (*model.ProductsResult, error)
+	GetBestSelling(ctx context.Context, limit int) ([]model.ProductDTO, error)
+	GetVariants(ctx context.Context, productID int64) ([]model.ProductDTO, error)
+	GetCategorys(ctx context.Context) ([]Category, error)
+	GetOnSale(ctx context.Context, offset, limit int, orderValue string) (*model.ProductsResult, error)
+	GetAttributes(ctx context.Context) ([]model.Attribute, error)
+}
+
+// ProductRepoConfig agrupa los parámetros de Odoo que usan las consultas del catálogo.
+type ProductRepoConfig struct {
+	// PricelistID es la tarifa activa con la que se calcula el precio efectivo.
+	PricelistID int64
+	// LocationID es la ubicación de stock (stock_location) del catálogo; 0 usa catalogLocationID.
+	LocationID int64
+	// Visibility es la política de publicación aplicada a todas las consultas del catálogo.
+	Visibility VisibilityPolicy
+	// QueryTimeout limita cada llamada al repositorio; 0 desactiva el límite.
+	QueryTimeout time.Duration
+	// OdooVersion es la versión mayor de Odoo (15, 16 o 17) con la que se eligen las
+	// variantes de las consultas; 0 asume 16.
+	OdooVersion int
+	// Holds resta del stock las reservas para recoger en tienda (cmd/internal/db/reservation.sql).
+	// Solo lo aplican las consultas SQL; el catálogo por JSON-RPC no ve la tabla.
+	Holds bool
 }
 
 // odooProductRepo es la implementación concreta que usa go-odoo internamente.
 type odooProductRepo struct {
-	DB *sql.DB
+	DB     db.Reader
+	config ProductRepoConfig
+	schema schema
 }
 
 // NewProductRepo construye un repository con un cliente Odoo ya iniciado.
-func NewProductRepo(d *sql.DB) ProductRepo {
-	return &odooProductRepo{DB: d}
+// Todas sus consultas son de lectura, así que d puede ser un *db.Cluster con réplicas.
+func NewProductRepo(d db.Reader, config ProductRepoConfig) ProductRepo {
+	return &odooProductRepo{DB: d, config: config, schema: schemaFor(config.OdooVersion)}
 }
 
 // GetAll recupera todos los productos (product.product) con paginación.
-func (r *odooProductRepo) GetAll(offset, limit int) (*model.ProductsResult, error) {
-	return r.GetFiltered(offset, limit, nil, nil, nil, nil, "", "")
+func (r *odooProductRepo) GetAll(ctx context.Context, offset, limit int) (*model.ProductsResult, error) {
+	return r.GetFiltered(ctx, offset, limit, nil, nil, nil, nil, "", "", nil)
 }
-func (r *odooProductRepo) GetByID(id int64) (*model.ProductDTO, error) {
-	query := fmt.Sprintf("WITH exist AS (SELECT product_id, SUM(quantity) as stock FROM stock_quant WHERE location_id = 8 AND product_id = %d  GROUP BY product_id HAVING SUM(quantity) > 0) SELECT product_id as id, product.name as name, pc.name as category, list_price as price, stock FROM (SELECT product_id, categ_id, name, list_price, stock FROM (SELECT product_id, stock, product_tmpl_id FROM exist e INNER JOIN product_product p ON p.id = e.product_id) INNER JOIN product_template pt ON product_tmpl_id = pt.id) product LEFT JOIN product_category pc ON pc.id = product.categ_id;", id)
+func (r *odooProductRepo) GetByID(ctx context.Context, id int64) (*model.ProductDTO, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+	query := fmt.Sprintf("WITH exist AS (SELECT product_id, SUM(quantity) as stock FROM %s quants WHERE location_id = %d AND product_id = %d AND %s GROUP BY product_id HAVING SUM(quantity) > 0) SELECT product_id as id, product.name as name, pc.name as category, list_price as price, stock FROM (SELECT product_id, categ_id, %s AS name, list_price, stock FROM (SELECT product_id, stock, product_tmpl_id FROM exist e INNER JOIN product_product p ON p.id = e.product_id) INNER JOIN product_template pt ON product_tmpl_id = pt.id) product LEFT JOIN product_category pc ON pc.id = product.categ_id;", stockSource(config), config.location(), id, config.Visibility.productFilter("product_id"), r.schema.name("pt", "product.template"))
 	var (
 		wg          sync.WaitGroup
 		errorImages error
@@ -56,9 +88,9 @@ func (r *odooProductRepo) GetByID(id int64) (*model.ProductDTO, error) {
 		defer wg.Done()
 		query := "SELECT res_id, mimetype, db_datas FROM ir_attachment WHERE length(db_datas) > 0 AND res_id = $1 AND (mimetype = 'image/png' OR mimetype = 'image/jpeg');"
 
-		rows, err := r.DB.Query(query, id)
+		rows, err := r.DB.QueryContext(ctx, query, id)
 		if err != nil {
-			errorImages = fmt.Errorf("error al obtener las imágenes: %v", err)
+			errorImages = fmt.Errorf("error al obtener las imágenes: %w", err)
 			return
 
 		}
@@ -78,7 +110,7 @@ func (r *odooProductRepo) GetByID(id int64) (*model.ProductDTO, error) {
 			images = append(images, fmt.Sprintf("data:%s;base64,", mime)+base64Str)
 		}
 	}()
-	row := r.DB.QueryRow(query)
+	row := r.DB.QueryRowContext(ctx, query)
 
 	if row == nil {
 		return nil, errors.New("error al obtener producto")
@@ -94,6 +126,10 @@ func (r *odooProductRepo) GetByID(id int64) (*model.ProductDTO, error) {
 	err := row.Scan(&product.ID, &name, &category, &product.OriginalPrice, &stock)
 	if err != nil {
 		log.Printf("Error to read row elemnt: %v\n", err)
+		if err != sql.ErrNoRows {
+			wg.Wait()
+			return nil, fmt.Errorf("error al obtener producto: %w", err)
+		}
 	}
 	product.Price = product.OriginalPrice
 
@@ -109,18 +145,29 @@ func (r *odooProductRepo) GetByID(id int64) (*model.ProductDTO, error) {
 		}
 	}
 	product.Stock = stock.Float64
+	if product.Components, err = r.getKitComponents(ctx, id, config.location()); err != nil {
+		log.Printf("Error en componentes: %v", err)
+	}
+	product.IsKit = len(product.Components) > 0
 	wg.Wait()
 	if errorImages != nil && errorImages != sql.ErrNoRows {
-		return nil, err
+		return nil, errorImages
 	}
 	product.Images = images
-	return &product, nil
+	products := []model.ProductDTO{product}
+	if err := r.attachPrices(ctx, config, products); err != nil {
+		return nil, err
+	}
+	if err := r.attachUnits(ctx, products); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
 
 }
 
 // GetRelated busca productos relacionados al productID dado.
-func (r *odooProductRepo) GetRelated(category, name string, offset, limit *int) (*model.ProductsResult, error) {
-	return r.GetFiltered(*offset, *limit, nil, nil, nil, []string{category}, name, "")
+func (r *odooProductRepo) GetRelated(ctx context.Context, category, name string, offset, limit *int) (*model.ProductsResult, error) {
+	return r.GetFiltered(ctx, *offset, *limit, nil, nil, nil, []string{category}, name, "", nil)
 }
 
 func getValueJson(json, fallback string) (string, error) {
@@ -153,244 +200,170 @@ func getValueJson(json, fallback string) (string, error) {
 	return str, nil
 }
 
-// GetFiltered permite filtrar por categoría (categ_id) y rango de precio list_price.
-// Ambos filtros son opcionales: pasar nil para omitir.
-func (r *odooProductRepo) GetFiltered(offset, limit int, categID, minPrice, maxPrice *int64, categorys []string, name, orderValue string) (*model.ProductsResult, error) {
-	var params, anys []any
-	stringsToanys := func(strings []string) []any {
-		anys := make([]any, len(strings))
-		for index, value := range strings {
-			anys[index] = value
-		}
-		return anys
-	}
-	wheres := []string{}
-	ProductsResult := &model.ProductsResult{}
-	i := 1
-	//const id_location = "8"
-	selectQuery := []string{", SUM(quantity) as stock", ", product.name as name, pc.name as category, list_price as price, stock", ", name, list_price, stock", ", stock"}
-	selectQueryCount := []string{}
-
-	for range selectQuery {
-		selectQueryCount = append(selectQueryCount, "")
+// catalogOrders es la lista blanca de órdenes aceptados por GetFiltered.
+// "asc" y "desc" se mantienen por compatibilidad y ordenan por precio.
+func (r *odooProductRepo) catalogOrders() map[string]string {
+	name := translated(r.schema.name("pt", "product.template"))
+	return map[string]string{
+		"":           "pp.id",
+		"asc":        "pt.list_price ASC, pp.id",
+		"desc":       "pt.list_price DESC, pp.id",
+		"price_asc":  "pt.list_price ASC, pp.id",
+		"price_desc": "pt.list_price DESC, pp.id",
+		"name_asc":   name + " ASC, pp.id",
+		"name_desc":  name + " DESC, pp.id",
+		"stock_desc": "e.stock DESC, pp.id",
+		"newest":     "pp.create_date DESC, pp.id DESC",
 	}
+}
 
-	exist := "WITH exist AS (SELECT product_id%s FROM stock_quant WHERE location_id = 8 GROUP BY product_id HAVING SUM(quantity) > 0"
+// catalogSelect arma el SELECT común de los listados: stock visible por producto unido a su
+// plantilla y categoría. El stock se filtra aparte con inStock para poder componerlo.
+func catalogSelect(config ProductRepoConfig, columns string) string {
+	return "WITH exist AS (SELECT product_id, SUM(quantity) AS stock FROM " + stockSource(config) + " quants WHERE location_id = " + strconv.FormatInt(config.location(), 10) + " AND " + config.Visibility.productFilter("product_id") + " GROUP BY product_id)" +
+		" SELECT " + columns + " FROM exist e" +
+		" INNER JOIN product_product pp ON pp.id = e.product_id" +
+		" INNER JOIN product_template pt ON pt.id = pp.product_tmpl_id" +
+		" LEFT JOIN product_category pc ON pc.id = pt.categ_id"
+}
 
-	const where = " WHERE"
+// inStock exige que el stock disponible sea mayor que min.
+func inStock(min float64) query.Filter {
+	return func(b *query.Builder) {
+		b.Where("e.stock > " + b.Arg(min))
+	}
+}
 
-	queryp := "SELECT product_id as id%s FROM (SELECT product_id, categ_id%s FROM (SELECT product_id%s, product_tmpl_id FROM exist e INNER JOIN product_product p ON p.id = e.product_id) INNER JOIN product_template pt ON product_tmpl_id = pt.id%s) product LEFT JOIN product_category pc ON pc.id = product.categ_id%s"
-	queryWhere := ""
+// GetFiltered permite filtrar por categoría (categ_id), rango de precio list_price,
+// nombre, nombres de categoría y valores de atributo (nombre del atributo -> valores aceptados).
+// Todos los filtros son opcionales: pasar nil para omitir.
+func (r *odooProductRepo) GetFiltered(ctx context.Context, offset, limit int, categID, minPrice, maxPrice *int64, categorys []string, name, orderValue string, attributes map[string][]string) (*model.ProductsResult, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+	ProductsResult := &model.ProductsResult{Products: []model.ProductDTO{}}
+
+	q := query.New().Apply(
+		inStock(0),
+		query.Equal("pt.categ_id", categID),
+		query.Between("pt.list_price", minPrice, maxPrice),
+		query.ContainsAny(translated(r.schema.name("pt", "product.template")), []string{name}),
+		query.ContainsAny("pc.name", categorys),
+		attributeFilter(r.schema, "pp.id", attributes),
+	)
+	countArgs := q.Args()
+	queryCount := catalogSelect(config, "COUNT(*) AS total") + q.WhereClause() + ";"
 
-	if minPrice != nil && maxPrice != nil {
-		limitPrice := fmt.Sprintf(" list_price >= %v and list_price <= %v", minPrice, maxPrice)
-		queryWhere += where + limitPrice
+	// El total sale de la misma consulta de la página con COUNT(*) OVER(),
+	// que se calcula antes de aplicar OFFSET/LIMIT.
+	q.OrderBy(orderValue, r.catalogOrders()).Page(offset, limit)
+	queryPage := catalogSelect(config, "pp.id, "+r.schema.name("pt", "product.template")+", pc.name, pt.list_price, e.stock, COUNT(*) OVER() AS total") + q.WhereClause() + q.OrderClause() + q.PageClause() + ";"
 
+	rows, err := r.DB.QueryContext(ctx, queryPage, q.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error en la consulta: %w", err)
 	}
-	if len(name) > 0 {
-		if len(queryWhere) > 0 {
-			queryWhere += " AND"
-		} else {
-			queryWhere += where
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			product  model.ProductDTO
+			stock    sql.NullFloat64
+			category sql.NullString
+			name     string
+		)
+		err := rows.Scan(&product.ID, &name, &category, &product.OriginalPrice, &stock, &ProductsResult.Total)
+		if err != nil {
+			log.Printf("Error to read row elemnt: %v\n", err)
+			continue
 		}
-		queryWhere += fmt.Sprintf(" product.name LIKE '%%' || $%d || '%%'", i)
-		params = append(params, name)
-		i++
-	}
-
-	orderBy := ""
-
-	if value := strings.ToLower(orderValue); value == "desc" || value == "asc" {
-		orderBy = fmt.Sprintf(" ORDER BY list_price %s", value)
-	}
-	wheres = append(wheres, queryWhere+orderBy)
-
-	queryWhere = ""
-	if len(categorys) > 0 {
+		product.Price = product.OriginalPrice
 
-		queryWhere = fmt.Sprintf("pc.name LIKE '%%'|| $%d || '%%'", i)
-		i++
-		for range len(categorys) - 1 {
-			queryWhere += fmt.Sprintf(" OR pc.name LIKE '%%' || $%d || '%%'", i)
-			i++
+		if product.Name, err = getValueJson(name, ""); err != nil {
+			log.Printf("Error en name: %v", err)
 		}
-		queryWhere = where + " ( " + queryWhere + " )"
-
-		anys := stringsToanys(categorys)
-		params = append(params, anys...)
-	}
-	wheres = append(wheres, queryWhere)
-
-	pag := fmt.Sprintf(" OFFSET %v LIMIT %v", offset, limit)
-
-	querypCount := queryp
-
-	existCount := exist + ")"
-
-	queryCount := ""
-
-	if len(wheres) == 0 {
-		queryCount = fmt.Sprintf(existCount+" SELECT COUNT(*) as total FROM exist;", "")
-	} else {
-		anys = stringsToanys(append(selectQueryCount, wheres...))
-		queryCount = fmt.Sprintf(existCount+" SELECT COUNT(*) as total FROM ("+querypCount+");", anys...)
+		if category.Valid {
+			product.CategoryName = category.String
+			product.Category = lastCategory(category.String)
+		}
+		product.Stock = stock.Float64
+		ProductsResult.Products = append(ProductsResult.Products, product)
 	}
-	if len(where) > 0 {
-		queryp += pag
-	} else {
-		exist += pag
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error leyendo productos: %w", err)
 	}
 
-	exist += ")"
-
-	anys = stringsToanys(append(selectQuery, wheres...))
-	query := fmt.Sprintf(exist+" "+queryp, anys...)
-	query += ";"
-
-	var (
-		wg               sync.WaitGroup
-		errQueryProducts error
-		ProductIndexMap  = make(map[uint64]int)
-	)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		rows, err := r.DB.Query(query, params...)
-		if err != nil {
-			errQueryProducts = fmt.Errorf("error en la consulta: %v", err)
-			return
-		}
-		defer rows.Close()
-		for i := 0; rows.Next(); i++ {
-			var (
-				stock    sql.NullFloat64
-				category sql.NullString
-				name     string
-			)
-			var product model.ProductDTO
-
-			err := rows.Scan(&product.ID, &name, &category, &product.OriginalPrice, &stock)
-			if err != nil {
-				log.Printf("Error to read row elemnt: %v\n", err)
-				continue
-			}
-			product.Price = product.OriginalPrice
-
-			if product.Name, err = getValueJson(name, ""); err != nil {
-				log.Printf("Error en name: %v", err)
+	// Una página vacía más allá del final no trae filas de las que leer el total.
+	if len(ProductsResult.Products) == 0 {
+		if offset > 0 {
+			if err := r.DB.QueryRowContext(ctx, queryCount, countArgs...).Scan(&ProductsResult.Total); err != nil {
+				return nil, fmt.Errorf("error scaning total product: %w", err)
 			}
-			if category.Valid {
-				product.CategoryName = category.String
-				strs := strings.Split(product.CategoryName, "/")
-				product.Category = strs[len(strs)-1]
-				if product.Category[0] == ' ' {
-					product.Category = product.Category[1:]
-				}
-			}
-			product.Stock = stock.Float64
-			ProductIndexMap[product.ID] = i
-			ProductsResult.Products = append(ProductsResult.Products, product)
 		}
-		errQueryProducts = nil
-	}()
-
-	row := r.DB.QueryRow(queryCount, params...)
+		return ProductsResult, nil
+	}
 
-	if row == nil {
-		err := fmt.Errorf("error geting total products: %v", row.Err())
+	if err := r.attachImages(ctx, ProductsResult.Products); err != nil {
 		return nil, err
 	}
-	if err := row.Scan(&ProductsResult.Total); err != nil {
-		err = fmt.Errorf("error scaning total product: %v", err)
+	if err := r.attachPrices(ctx, config, ProductsResult.Products); err != nil {
 		return nil, err
 	}
-	if ProductsResult.Total == 0 {
-		return nil, errors.New("no products found")
-	}
-	wg.Wait()
-
-	if errQueryProducts != nil {
-		return nil, errQueryProducts
-	}
-
-	query = "SELECT res_id, mimetype, db_datas FROM ir_attachment WHERE length(db_datas) > 0 AND (mimetype = 'image/png' OR mimetype = 'image/jpeg') AND ("
-	for id := range ProductIndexMap {
-		query += fmt.Sprintf(" res_id = %d OR", id)
-	}
-	query = query[:len(query)-3] + " );"
-	rows, err := r.DB.Query(query)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var (
-				id       uint64
-				mime     string
-				db_datas []byte
-			)
-			if err = rows.Scan(&id, &mime, &db_datas); err != nil {
-				log.Printf("error al leer el valor de db_datas: %v", err)
-				continue
-			}
-
-			base64Str := base64.StdEncoding.EncodeToString(db_datas)
-			ProductsResult.Products[ProductIndexMap[id]].Images = append(ProductsResult.Products[ProductIndexMap[id]].Images, fmt.Sprintf("data:%s;base64,", mime)+base64Str)
-		}
-
-	} else if err != sql.ErrNoRows {
-		return nil, fmt.Errorf("error al obtener las imágenes: %v", err)
+	if err := r.attachUnits(ctx, ProductsResult.Products); err != nil {
+		return nil, err
 	}
 
 	return ProductsResult, nil
 }
-func (r *odooProductRepo) GetCategorys() ([]Category, error) {
+func (r *odooProductRepo) GetCategorys(ctx context.Context) ([]Category, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
 	var categorys []Category
 
-	row, err := r.DB.Query("SELECT name FROM product_category")
+	row, err := r.DB.QueryContext(ctx, "SELECT pc.name FROM product_category pc WHERE "+r.config.forStore(ctx).Visibility.categoryFilter("pc"))
 	if err != nil {
-		err = errors.New("error al obtener las categorías")
-		return nil, err
+		return nil, fmt.Errorf("error al obtener las categorías: %w", err)
 	}
 	defer row.Close()
-	exist := func(category string) bool {
-		for _, cat := range categorys {
-			if category == cat.Category {
-				return true
-			}
-		}
-		return false
-	}
 	for row.Next() {
-		var category Category
+		var name string
 
-		if err = row.Scan(&category.CategoryName); err != nil {
+		if err = row.Scan(&name); err != nil {
 			log.Printf("error al leer el valor de category: %v", err)
 			continue
 		}
-		strs := strings.Split(category.CategoryName, "/")
-		if len(strs) == 2 || len(strs) == 3 {
-			continue
-		}
-		category.Category = strs[len(strs)-1]
-		if category.Category[0] == ' ' {
-			category.Category = category.Category[1:]
-		}
-		if !exist(category.Category) {
-			categorys = append(categorys, category)
-		}
-
+		categorys = appendCategory(categorys, name)
 	}
 
 	return categorys, nil
 }
 
+// appendCategory añade la categoría por el último segmento de su ruta, sin repetir.
+// Las rutas de dos y tres niveles no se listan.
+func appendCategory(categorys []Category, name string) []Category {
+	strs := strings.Split(name, "/")
+	if len(strs) == 2 || len(strs) == 3 {
+		return categorys
+	}
+	category := Category{CategoryName: name, Category: strings.TrimLeft(strs[len(strs)-1], " ")}
+	for _, cat := range categorys {
+		if category.Category == cat.Category {
+			return categorys
+		}
+	}
+	return append(categorys, category)
+}
+
 // GetBestSelling ordena por “sale_count” (campo de product.template) descendente y devuelve las variantes más vendidas.
 // Para conseguir sale_count hay que leer primero del template.
-func (r *odooProductRepo) GetBestSelling(limit int) ([]model.ProductDTO, error) {
-	query := fmt.Sprintf("WITH exist AS (SELECT product_id, SUM(quantity) as stock FROM stock_quant WHERE location_id = 8 GROUP BY product_id having sum(quantity)>0) select product_id, pct.name as name , pc.name as category, price, stock  from (select product_id, stock, categ_id, name, list_price as price, quantity from (select product_id, stock, product_tmpl_id, quantity from (select exist.product_id, stock, quantity from (select product_id, sum(quantity_done) as quantity from stock_move where location_dest_id = 5 group by product_id) l inner join exist on l.product_id = exist.product_id) o inner join product_product pp on pp.id = o.product_id) ptl inner join product_template pt on pt.id = ptl.product_tmpl_id) pct inner join product_category pc on pct.categ_id = pc.id order by quantity desc limit %d", limit)
+func (r *odooProductRepo) GetBestSelling(ctx context.Context, limit int) ([]model.ProductDTO, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+	query := fmt.Sprintf("WITH exist AS (SELECT product_id, SUM(quantity) as stock FROM %s quants WHERE location_id = %d AND %s GROUP BY product_id having sum(quantity)>0) select product_id, pct.name as name , pc.name as category, price, stock  from (select product_id, stock, categ_id, %s as name, list_price as price, quantity from (select product_id, stock, product_tmpl_id, quantity from (select exist.product_id, stock, quantity from (select product_id, sum(%s) as quantity from stock_move where location_dest_id = 5 group by product_id) l inner join exist on l.product_id = exist.product_id) o inner join product_product pp on pp.id = o.product_id) ptl inner join product_template pt on pt.id = ptl.product_tmpl_id) pct inner join product_category pc on pct.categ_id = pc.id order by quantity desc limit %d", stockSource(config), config.location(), config.Visibility.productFilter("product_id"), r.schema.name("pt", "product.template"), r.schema.moveQuantity(), limit)
 
-	rows, err := r.DB.Query(query)
+	rows, err := r.DB.QueryContext(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("ha ocurrido un error: %v", err)
+		return nil, fmt.Errorf("ha ocurrido un error: %w", err)
 	}
 	defer rows.Close()
 	var Products []model.ProductDTO
@@ -423,10 +396,129 @@ func (r *odooProductRepo) GetBestSelling(limit int) ([]model.ProductDTO, error)
 		product.Stock = stock.Float64
 		Products = append(Products, product)
 	}
+	if err := r.attachPrices(ctx, config, Products); err != nil {
+		return nil, err
+	}
+	if err := r.attachUnits(ctx, Products); err != nil {
+		return nil, err
+	}
 	return Products, nil
 }
 
 // GetVariants busca todas las variantes del mismo template al que pertenece productID.
-func (r *odooProductRepo) GetVariants(productID int64) ([]model.ProductDTO, error) {
+func (r *odooProductRepo) GetVariants(ctx context.Context, productID int64) ([]model.ProductDTO, error) {
 	return []model.ProductDTO{}, nil
 }
+
+// effectivePriceQuery calcula el precio de cada producto visible con stock según la tarifa $1.
+func (r *odooProductRepo) effectivePriceQuery(config ProductRepoConfig) string {
+	return `WITH exist AS (SELECT product_id, SUM(quantity) AS stock FROM ` + stockSource(config) + ` quants WHERE location_id = ` + strconv.FormatInt(config.location(), 10) + ` AND ` + config.Visibility.productFilter("product_id") + ` GROUP BY product_id HAVING SUM(quantity) > 0),
+priced AS (
+	SELECT pp.id, ` + r.schema.name("pt", "product.template") + ` AS name, pc.name AS category, pt.list_price, e.stock, item.date_end, ` + effectivePrice + ` AS price
+	FROM exist e
+	INNER JOIN product_product pp ON pp.id = e.product_id
+	INNER JOIN product_template pt ON pt.id = pp.product_tmpl_id
+	LEFT JOIN product_category pc ON pc.id = pt.categ_id
+	INNER JOIN LATERAL (` + pricelistItem(r.schema) + `) item ON true
+)`
+}
+
+// GetOnSale devuelve los productos cuyo precio efectivo en la tarifa activa es menor que list_price,
+// ordenados por porcentaje de descuento (desc por defecto).
+func (r *odooProductRepo) GetOnSale(ctx context.Context, offset, limit int, orderValue string) (*model.ProductsResult, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+	order := "DESC"
+	if strings.ToLower(orderValue) == "asc" {
+		order = "ASC"
+	}
+	query := r.effectivePriceQuery(config) + fmt.Sprintf(` SELECT id, name, category, list_price, price, ROUND((1 - price / list_price) * 100, 2) AS discount, date_end, stock, COUNT(*) OVER() AS total
+	FROM priced WHERE list_price > 0 AND price < list_price
+	ORDER BY discount %s, id OFFSET $2 LIMIT $3;`, order)
+
+	rows, err := r.DB.QueryContext(ctx, query, config.PricelistID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error en la consulta: %w", err)
+	}
+	defer rows.Close()
+
+	result := &model.ProductsResult{Products: []model.ProductDTO{}}
+	for rows.Next() {
+		var (
+			product  model.ProductDTO
+			stock    sql.NullFloat64
+			category sql.NullString
+			dateEnd  sql.NullTime
+			name     string
+		)
+		err := rows.Scan(&product.ID, &name, &category, &product.OriginalPrice, &product.Price, &product.Discount, &dateEnd, &stock, &result.Total)
+		if err != nil {
+			log.Printf("Error to read row elemnt: %v\n", err)
+			continue
+		}
+		if product.Name, err = getValueJson(name, ""); err != nil {
+			log.Printf("Error en name: %v", err)
+		}
+		if category.Valid {
+			product.CategoryName = category.String
+			product.Category = lastCategory(category.String)
+		}
+		if dateEnd.Valid {
+			product.SaleEndDate = &dateEnd.Time
+		}
+		product.Stock = stock.Float64
+		result.Products = append(result.Products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error leyendo productos en oferta: %w", err)
+	}
+
+	if err := r.attachImages(ctx, result.Products); err != nil {
+		return nil, err
+	}
+	if err := r.attachUnits(ctx, result.Products); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// lastCategory devuelve el último segmento de una ruta de categoría "A / B / C".
+func lastCategory(path string) string {
+	strs := strings.Split(path, "/")
+	return strings.TrimSpace(strs[len(strs)-1])
+}
+
+// attachImages carga en un único query las imágenes de los productos dados.
+func (r *odooProductRepo) attachImages(ctx context.Context, products []model.ProductDTO) error {
+	if len(products) == 0 {
+		return nil
+	}
+	index := make(map[uint64]int, len(products))
+	ids := make([]int64, len(products))
+	for i, p := range products {
+		index[p.ID] = i
+		ids[i] = int64(p.ID)
+	}
+
+	query := "SELECT res_id, mimetype, db_datas FROM ir_attachment WHERE length(db_datas) > 0 AND (mimetype = 'image/png' OR mimetype = 'image/jpeg') AND res_id = ANY($1);"
+	rows, err := r.DB.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error al obtener las imágenes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id       uint64
+			mime     string
+			db_datas []byte
+		)
+		if err = rows.Scan(&id, &mime, &db_datas); err != nil {
+			log.Printf("error al leer el valor de db_datas: %v", err)
+			continue
+		}
+		i := index[id]
+		products[i].Images = append(products[i].Images, fmt.Sprintf("data:%s;base64,", mime)+base64.StdEncoding.EncodeToString(db_datas))
+	}
+	return rows.Err()
+}
@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrReservationNotFound indica que no hay ninguna reserva con ese código.
+	ErrReservationNotFound = errors.New("reserva no encontrada")
+	// ErrReservationClosed indica que la reserva ya se recogió, se anuló o caducó.
+	ErrReservationClosed = errors.New("la reserva ya no está activa")
+)
+
+// Estados de una reserva (stock_hold.state).
+const (
+	ReservationActive    = "active"
+	ReservationCollected = "collected"
+	ReservationCancelled = "cancelled"
+	ReservationExpired   = "expired"
+)
+
+// activeHolds tiene la forma de stock_quant con las reservas vigentes en negativo; stockSource
+// lo añade cuando ProductRepoConfig.Holds está activo. Una reserva caducada deja de contar
+// aunque el barrido todavía no la haya marcado.
+const activeHolds = `SELECT l.product_id, h.location_id, -l.quantity FROM stock_hold_line l
+	INNER JOIN stock_hold h ON h.id = l.hold_id
+	WHERE h.state = 'active' AND h.expires_at > now()`
+
+// HeldStock da lo retenido por las reservas vigentes. El stock que devuelve Odoo por
+// JSON-RPC no las conoce, así que rpcOrderRepo lo resta al comprobar un pedido.
+type HeldStock interface {
+	// Held suma por producto lo reservado en location y en sus ubicaciones hijas, igual que
+	// qty_available con esa ubicación en el contexto.
+	Held(ctx context.Context, location int64, productIDs []int64) (map[int64]float64, error)
+}
+
+// ReservationRepo guarda las reservas para recoger en tienda (cmd/internal/db/reservation.sql).
+type ReservationRepo interface {
+	HeldStock
+	// Create comprueba el stock libre en la ubicación de la tienda y retiene las líneas
+	// durante ttl. Si alguna no se puede reservar devuelve un *OrderRejectedError.
+	Create(ctx context.Context, shop model.Shop, req model.ReservationRequest, ttl time.Duration) (*model.Reservation, error)
+	Get(ctx context.Context, code string) (*model.Reservation, error)
+	// Close pasa una reserva activa al estado dado; ErrReservationClosed si ya no lo estaba.
+	Close(ctx context.Context, code, state string) (*model.Reservation, error)
+	// Expire marca como caducadas las reservas activas vencidas y las devuelve.
+	Expire(ctx context.Context) ([]model.Reservation, error)
+}
+
+type sqlReservationRepo struct {
+	db     *sql.DB
+	config ProductRepoConfig
+}
+
+// NewReservationRepo construye el repositorio de reservas. Escribe, así que db es el primario.
+func NewReservationRepo(db *sql.DB, config ProductRepoConfig) ReservationRepo {
+	config.Holds = true
+	return &sqlReservationRepo{db: db, config: config}
+}
+
+// codeAlphabet no tiene caracteres que se confundan al dictarlos (0/O, 1/I).
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func pickupCode() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	for i, b := range raw {
+		raw[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(raw), nil
+}
+
+func (r *sqlReservationRepo) Create(ctx context.Context, shop model.Shop, req model.ReservationRequest, ttl time.Duration) (*model.Reservation, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+	config.LocationID = shop.LocationID
+
+	quantities := make(map[int64]float64, len(req.Lines))
+	ids := make([]int64, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		if _, ok := quantities[line.ProductID]; !ok {
+			ids = append(ids, line.ProductID)
+		}
+		quantities[line.ProductID] += line.Quantity
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting reservation: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Las reservas de una misma ubicación se serializan para no vender dos veces el mismo stock.
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('stock_hold'), $1)", shop.LocationID); err != nil {
+		return nil, fmt.Errorf("error locking location %d: %w", shop.LocationID, err)
+	}
+
+	query := `WITH stock AS (SELECT product_id, SUM(quantity) AS qty FROM ` + stockSource(config) + ` quants
+		WHERE location_id = ` + strconv.FormatInt(config.location(), 10) + ` AND product_id = ANY($1) GROUP BY product_id)
+	SELECT p.id, COALESCE(st.qty, 0) FROM product_product p LEFT JOIN stock st ON st.product_id = p.id
+	WHERE p.id = ANY($1) AND ` + config.Visibility.productFilter("p.id")
+	rows, err := tx.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("error checking stock: %w", err)
+	}
+	available := make(map[int64]float64, len(ids))
+	for rows.Next() {
+		var id int64
+		var qty float64
+		if err := rows.Scan(&id, &qty); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error checking stock: %w", err)
+		}
+		available[id] = qty
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error checking stock: %w", err)
+	}
+
+	var problems []model.OrderLineProblem
+	for _, id := range ids {
+		stock, ok := available[id]
+		switch {
+		case !ok:
+			problems = append(problems, model.OrderLineProblem{ProductID: id, Reason: "unavailable"})
+		case stock < quantities[id]:
+			problems = append(problems, model.OrderLineProblem{ProductID: id, Reason: "insufficient_stock", Stock: max(stock, 0)})
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &OrderRejectedError{Problems: problems}
+	}
+
+	customer := req.Customer
+	var holdID int64
+	var code string
+	for attempt := 0; holdID == 0; attempt++ {
+		if attempt == 5 {
+			return nil, errors.New("error generating a unique pickup code")
+		}
+		if code, err = pickupCode(); err != nil {
+			return nil, err
+		}
+		err = tx.QueryRowContext(ctx, `INSERT INTO stock_hold (code, shop_id, location_id, customer_name, email, phone, expires_at)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), now() + $7 * interval '1 second')
+			ON CONFLICT (code) DO NOTHING RETURNING id`,
+			code, shop.ID, shop.LocationID, strings.TrimSpace(customer.Name), strings.TrimSpace(customer.Email), strings.TrimSpace(customer.Phone), int64(ttl.Seconds())).Scan(&holdID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("error creating reservation: %w", err)
+		}
+	}
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO stock_hold_line (hold_id, product_id, quantity) VALUES ($1, $2, $3)", holdID, id, quantities[id]); err != nil {
+			return nil, fmt.Errorf("error creating reservation line: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error creating reservation: %w", err)
+	}
+	return r.get(ctx, code)
+}
+
+func (r *sqlReservationRepo) Held(ctx context.Context, location int64, productIDs []int64) (map[int64]float64, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT l.product_id, SUM(l.quantity) FROM stock_hold_line l
+	INNER JOIN stock_hold h ON h.id = l.hold_id
+	INNER JOIN stock_location hl ON hl.id = h.location_id
+	INNER JOIN stock_location root ON root.id = $1
+	WHERE h.state = 'active' AND h.expires_at > now() AND l.product_id = ANY($2)
+		AND (hl.id = root.id OR hl.parent_path LIKE root.parent_path || '%')
+	GROUP BY l.product_id`
+	rows, err := r.db.QueryContext(ctx, query, location, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error reading held stock: %w", err)
+	}
+	defer rows.Close()
+	held := make(map[int64]float64, len(productIDs))
+	for rows.Next() {
+		var id int64
+		var qty float64
+		if err := rows.Scan(&id, &qty); err != nil {
+			return nil, fmt.Errorf("error reading held stock: %w", err)
+		}
+		held[id] = qty
+	}
+	return held, rows.Err()
+}
+
+func (r *sqlReservationRepo) Get(ctx context.Context, code string) (*model.Reservation, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	return r.get(ctx, code)
+}
+
+func (r *sqlReservationRepo) get(ctx context.Context, code string) (*model.Reservation, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	var (
+		res    model.Reservation
+		holdID int64
+	)
+	// Una reserva activa ya vencida se muestra caducada aunque el barrido no haya pasado.
+	query := `SELECT h.id, h.code, CASE WHEN h.state = 'active' AND h.expires_at <= now() THEN 'expired' ELSE h.state END,
+		h.shop_id, COALESCE(pc.name, ''), h.location_id, h.customer_name, h.email, COALESCE(h.phone, ''), h.expires_at, h.created_at
+	FROM stock_hold h LEFT JOIN pos_config pc ON pc.id = h.shop_id
+	WHERE h.code = $1`
+	err := r.db.QueryRowContext(ctx, query, code).Scan(&holdID, &res.Code, &res.State, &res.Shop.ID, &res.Shop.Name, &res.Shop.LocationID,
+		&res.CustomerName, &res.Email, &res.Phone, &res.ExpiresAt, &res.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving reservation: %w", err)
+	}
+	res.Shop.Kind = ShopKindPOS
+
+	rows, err := r.db.QueryContext(ctx, "SELECT product_id, quantity FROM stock_hold_line WHERE hold_id = $1 ORDER BY id", holdID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving reservation lines: %w", err)
+	}
+	defer rows.Close()
+	res.Lines = []model.ReservationLine{}
+	for rows.Next() {
+		var line model.ReservationLine
+		if err := rows.Scan(&line.ProductID, &line.Quantity); err != nil {
+			return nil, fmt.Errorf("error retrieving reservation lines: %w", err)
+		}
+		res.Lines = append(res.Lines, line)
+	}
+	return &res, rows.Err()
+}
+
+func (r *sqlReservationRepo) Close(ctx context.Context, code, state string) (*model.Reservation, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	res, err := r.db.ExecContext(ctx, "UPDATE stock_hold SET state = $2 WHERE code = $1 AND state = 'active' AND expires_at > now()", code, state)
+	if err != nil {
+		return nil, fmt.Errorf("error updating reservation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("error updating reservation: %w", err)
+	}
+	reservation, err := r.get(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrReservationClosed
+	}
+	return reservation, nil
+}
+
+func (r *sqlReservationRepo) Expire(ctx context.Context) ([]model.Reservation, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "UPDATE stock_hold SET state = 'expired' WHERE state = 'active' AND expires_at <= now() RETURNING code")
+	if err != nil {
+		return nil, fmt.Errorf("error expiring reservations: %w", err)
+	}
+	var codes []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error expiring reservations: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error expiring reservations: %w", err)
+	}
+
+	expired := make([]model.Reservation, 0, len(codes))
+	for _, code := range codes {
+		reservation, err := r.get(ctx, code)
+		if err != nil {
+			return expired, err
+		}
+		expired = append(expired, *reservation)
+	}
+	return expired, nil
+}
@@ -0,0 +1,808 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// rpcProductRepo implementa ProductRepo con la API JSON-RPC de Odoo en lugar de leer
+// sus tablas. Devuelve los mismos datos que odooProductRepo salvo donde se indica.
+type rpcProductRepo struct {
+	client *odoorpc.Client
+	config ProductRepoConfig
+	schema schema
+}
+
+// NewRPCProductRepo construye el repositorio sobre un cliente de Odoo.
+func NewRPCProductRepo(client *odoorpc.Client, config ProductRepoConfig) ProductRepo {
+	return &rpcProductRepo{client: client, config: config, schema: schemaFor(config.OdooVersion)}
+}
+
+// customerLocationID es la ubicación de clientes (stock.stock_location_customers), destino
+// de las entregas que cuentan como venta en GetBestSelling.
+const customerLocationID = 5
+
+// stockContext hace que qty_available sea el stock de la ubicación del catálogo y, con una
+// compañía, limita la sesión a ella. Odoo incluye las ubicaciones hijas y calcula el stock
+// de los kits por sus componentes.
+func stockContext(config ProductRepoConfig) map[string]any {
+	context := map[string]any{"location": config.location()}
+	if id := config.Visibility.CompanyID; id > 0 {
+		context["allowed_company_ids"] = []int64{id}
+	}
+	return context
+}
+
+type rpcProduct struct {
+	ID           int64            `json:"id"`
+	Name         odoorpc.String   `json:"name"`
+	Template     odoorpc.Many2one `json:"product_tmpl_id"`
+	Category     odoorpc.Many2one `json:"categ_id"`
+	ListPrice    float64          `json:"list_price"`
+	QtyAvailable float64          `json:"qty_available"`
+	Uom          odoorpc.Many2one `json:"uom_id"`
+	Image        odoorpc.String   `json:"image_1920"`
+}
+
+var rpcProductFields = []string{"name", "product_tmpl_id", "categ_id", "list_price", "qty_available", "uom_id", "image_1920"}
+
+// rpcOrders es la lista blanca de órdenes, con las mismas claves que catalogOrders.
+// qty_available no se puede ordenar en Odoo (no se guarda), así que stock_desc usa el id.
+var rpcOrders = map[string]string{
+	"":           "id",
+	"asc":        "list_price asc, id",
+	"desc":       "list_price desc, id",
+	"price_asc":  "list_price asc, id",
+	"price_desc": "list_price desc, id",
+	"name_asc":   "name asc, id",
+	"name_desc":  "name desc, id",
+	"stock_desc": "id",
+	"newest":     "create_date desc, id desc",
+}
+
+// visibility traduce la VisibilityPolicy a un dominio sobre product.product.
+// Los archivados ya los excluye Odoo en las búsquedas.
+func visibility(config ProductRepoConfig) odoorpc.Domain {
+	var domain odoorpc.Domain
+	if config.Visibility.SaleOk {
+		domain = append(domain, odoorpc.Cond("sale_ok", "=", true))
+	}
+	if config.Visibility.Published {
+		domain = append(domain, odoorpc.Cond("is_published", "=", true))
+	}
+	if id := config.Visibility.CompanyID; id > 0 {
+		domain = append(domain, odoorpc.Or(odoorpc.Cond("company_id", "=", false), odoorpc.Cond("company_id", "=", id))...)
+	}
+	return domain
+}
+
+// inStockDomain son los productos visibles con stock en la ubicación del catálogo.
+func inStockDomain(config ProductRepoConfig) odoorpc.Domain {
+	return append(visibility(config), odoorpc.Cond("qty_available", ">", 0))
+}
+
+func (r *rpcProductRepo) GetAll(ctx context.Context, offset, limit int) (*model.ProductsResult, error) {
+	return r.GetFiltered(ctx, offset, limit, nil, nil, nil, nil, "", "", nil)
+}
+
+func (r *rpcProductRepo) GetRelated(ctx context.Context, category, name string, offset, limit *int) (*model.ProductsResult, error) {
+	return r.GetFiltered(ctx, *offset, *limit, nil, nil, nil, []string{category}, name, "", nil)
+}
+
+func (r *rpcProductRepo) GetFiltered(ctx context.Context, offset, limit int, categID, minPrice, maxPrice *int64, categorys []string, name, orderValue string, attributes map[string][]string) (*model.ProductsResult, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	domain := inStockDomain(config)
+	if categID != nil {
+		domain = append(domain, odoorpc.Cond("categ_id", "=", *categID))
+	}
+	if minPrice != nil {
+		domain = append(domain, odoorpc.Cond("list_price", ">=", *minPrice))
+	}
+	if maxPrice != nil {
+		domain = append(domain, odoorpc.Cond("list_price", "<=", *maxPrice))
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		domain = append(domain, odoorpc.Cond("name", "ilike", name))
+	}
+	var byCategory [][]any
+	for _, category := range categorys {
+		if category = strings.TrimSpace(category); category != "" {
+			byCategory = append(byCategory, odoorpc.Cond("categ_id.complete_name", "ilike", category))
+		}
+	}
+	if len(byCategory) > 0 {
+		domain = append(domain, odoorpc.Or(byCategory...)...)
+	}
+	attributeDomain, err := r.attributeDomain(ctx, attributes)
+	if err != nil {
+		return nil, err
+	}
+	domain = append(domain, attributeDomain...)
+
+	order, ok := rpcOrders[strings.ToLower(strings.TrimSpace(orderValue))]
+	if !ok {
+		order = rpcOrders[""]
+	}
+	total, err := r.client.SearchCount(ctx, "product.product", domain, stockContext(config))
+	if err != nil {
+		return nil, fmt.Errorf("error en la consulta: %w", err)
+	}
+	result := &model.ProductsResult{Products: []model.ProductDTO{}, Total: uint(total)}
+	if total == 0 || offset >= total {
+		return result, nil
+	}
+
+	var rows []rpcProduct
+	opts := odoorpc.SearchOptions{Fields: rpcProductFields, Offset: offset, Limit: limit, Order: order, Context: stockContext(config)}
+	if err := r.client.SearchRead(ctx, "product.product", domain, opts, &rows); err != nil {
+		return nil, fmt.Errorf("error en la consulta: %w", err)
+	}
+	result.Products = r.toDTOs(rows)
+	if err := r.attachPrices(ctx, config, result.Products, rows); err != nil {
+		return nil, err
+	}
+	if err := r.attachUnits(ctx, result.Products, rows); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// attributeDomain exige, para cada atributo, alguno de los valores pedidos. Los atributos
+// que crean variantes se comprueban en la combinación de la variante; los que no
+// (no_variant) en la plantilla.
+func (r *rpcProductRepo) attributeDomain(ctx context.Context, attributes map[string][]string) (odoorpc.Domain, error) {
+	names := make([]string, 0, len(attributes))
+	for name, values := range attributes {
+		if len(values) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var domain odoorpc.Domain
+	for _, name := range names {
+		var ptavs []struct {
+			ID       int64            `json:"id"`
+			Template odoorpc.Many2one `json:"product_tmpl_id"`
+		}
+		base := odoorpc.Domain{
+			odoorpc.Cond("attribute_id.name", "=", name),
+			odoorpc.Cond("name", "in", attributes[name]),
+			odoorpc.Cond("ptav_active", "=", true),
+		}
+		var variantIDs, templateIDs []int64
+		for _, noVariant := range []bool{false, true} {
+			operator := "!="
+			if noVariant {
+				operator = "="
+			}
+			cond := append(base[:len(base):len(base)], odoorpc.Cond("attribute_id.create_variant", operator, "no_variant"))
+			opts := odoorpc.SearchOptions{Fields: []string{"product_tmpl_id"}}
+			if err := r.client.SearchRead(ctx, "product.template.attribute.value", cond, opts, &ptavs); err != nil {
+				return nil, fmt.Errorf("error al filtrar por atributos: %w", err)
+			}
+			for _, ptav := range ptavs {
+				if noVariant {
+					templateIDs = append(templateIDs, ptav.Template.ID)
+				} else {
+					variantIDs = append(variantIDs, ptav.ID)
+				}
+			}
+		}
+		domain = append(domain, odoorpc.Or(
+			odoorpc.Cond("product_template_attribute_value_ids", "in", nonNil(variantIDs)),
+			odoorpc.Cond("product_tmpl_id", "in", nonNil(templateIDs)),
+		)...)
+	}
+	return domain, nil
+}
+
+func nonNil(ids []int64) []int64 {
+	if ids == nil {
+		return []int64{}
+	}
+	return ids
+}
+
+func (r *rpcProductRepo) toDTOs(rows []rpcProduct) []model.ProductDTO {
+	products := make([]model.ProductDTO, 0, len(rows))
+	for _, row := range rows {
+		product := model.ProductDTO{
+			ID:            uint64(row.ID),
+			Name:          string(row.Name),
+			OriginalPrice: row.ListPrice,
+			Price:         row.ListPrice,
+			Stock:         row.QtyAvailable,
+		}
+		if row.Category.ID != 0 {
+			product.CategoryName = row.Category.Name
+			product.Category = lastCategory(row.Category.Name)
+		}
+		if image := dataURI(string(row.Image)); image != "" {
+			product.Images = []string{image}
+		}
+		products = append(products, product)
+	}
+	return products
+}
+
+// dataURI convierte un campo Image de Odoo (base64) en data URI. Como odooProductRepo,
+// solo se devuelven PNG y JPEG.
+func dataURI(image string) string {
+	switch {
+	case strings.HasPrefix(image, "iVBOR"):
+		return "data:image/png;base64," + image
+	case strings.HasPrefix(image, "/9j/"):
+		return "data:image/jpeg;base64," + image
+	}
+	return ""
+}
+
+// attachUnits carga la unidad de venta y los empaquetados de venta, y redondea el stock.
+func (r *rpcProductRepo) attachUnits(ctx context.Context, products []model.ProductDTO, rows []rpcProduct) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(rows))
+	var uomIDs []int64
+	seen := map[int64]bool{}
+	for i, row := range rows {
+		ids[i] = row.ID
+		if row.Uom.ID != 0 && !seen[row.Uom.ID] {
+			seen[row.Uom.ID] = true
+			uomIDs = append(uomIDs, row.Uom.ID)
+		}
+	}
+
+	var uoms []struct {
+		ID       int64          `json:"id"`
+		Name     odoorpc.String `json:"name"`
+		Rounding float64        `json:"rounding"`
+	}
+	if len(uomIDs) > 0 {
+		if err := r.client.Read(ctx, "uom.uom", uomIDs, []string{"name", "rounding"}, nil, &uoms); err != nil {
+			return fmt.Errorf("error al obtener las unidades de medida: %w", err)
+		}
+	}
+	byID := make(map[int64]model.UomDTO, len(uoms))
+	for _, uom := range uoms {
+		byID[uom.ID] = model.UomDTO{Name: string(uom.Name), Rounding: uom.Rounding}
+	}
+	index := make(map[int64]int, len(rows))
+	for i, row := range rows {
+		index[row.ID] = i
+		if uom, ok := byID[row.Uom.ID]; ok {
+			products[i].Uom = &uom
+			products[i].Stock = roundToUom(products[i].Stock, uom.Rounding)
+		}
+	}
+
+	var packagings []struct {
+		ID      int64            `json:"id"`
+		Product odoorpc.Many2one `json:"product_id"`
+		Name    odoorpc.String   `json:"name"`
+		Qty     float64          `json:"qty"`
+		Barcode odoorpc.String   `json:"barcode"`
+	}
+	domain := odoorpc.Domain{odoorpc.Cond("product_id", "in", ids), odoorpc.Cond("sales", "=", true)}
+	opts := odoorpc.SearchOptions{Fields: []string{"product_id", "name", "qty", "barcode"}, Order: "sequence, id"}
+	if err := r.client.SearchRead(ctx, "product.packaging", domain, opts, &packagings); err != nil {
+		return fmt.Errorf("error al obtener los empaquetados: %w", err)
+	}
+	for _, p := range packagings {
+		i, ok := index[p.Product.ID]
+		if !ok {
+			continue
+		}
+		products[i].Packagings = append(products[i].Packagings, model.PackagingDTO{ID: p.ID, Name: string(p.Name), Qty: p.Qty, Barcode: string(p.Barcode)})
+	}
+	return nil
+}
+
+// GetByID devuelve el producto si es visible y tiene stock, con sus componentes si es un kit.
+func (r *rpcProductRepo) GetByID(ctx context.Context, id int64) (*model.ProductDTO, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	var rows []rpcProduct
+	domain := append(inStockDomain(config), odoorpc.Cond("id", "=", id))
+	opts := odoorpc.SearchOptions{Fields: rpcProductFields, Limit: 1, Context: stockContext(config)}
+	if err := r.client.SearchRead(ctx, "product.product", domain, opts, &rows); err != nil {
+		return nil, fmt.Errorf("error al obtener producto: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("error al obtener producto: %d no encontrado", id)
+	}
+	products := r.toDTOs(rows)
+	components, err := r.kitComponents(ctx, config, rows[0])
+	if err != nil {
+		return nil, err
+	}
+	products[0].Components = components
+	products[0].IsKit = len(components) > 0
+	if err := r.attachPrices(ctx, config, products, rows); err != nil {
+		return nil, err
+	}
+	if err := r.attachUnits(ctx, products, rows); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+// kitComponents devuelve los componentes de la lista de materiales tipo kit del producto,
+// prefiriendo la propia de la variante a la de la plantilla, o nil si no es un kit.
+func (r *rpcProductRepo) kitComponents(ctx context.Context, config ProductRepoConfig, product rpcProduct) ([]model.KitComponent, error) {
+	var boms []struct {
+		ID         int64   `json:"id"`
+		ProductQty float64 `json:"product_qty"`
+	}
+	domain := odoorpc.Domain{
+		odoorpc.Cond("type", "=", "phantom"),
+		"|", odoorpc.Cond("product_id", "=", product.ID),
+		"&", odoorpc.Cond("product_id", "=", false), odoorpc.Cond("product_tmpl_id", "=", product.Template.ID),
+	}
+	opts := odoorpc.SearchOptions{Fields: []string{"product_qty"}, Order: "product_id, sequence, id", Limit: 1}
+	if err := r.client.SearchRead(ctx, "mrp.bom", domain, opts, &boms); err != nil {
+		return nil, fmt.Errorf("error al obtener los componentes del kit: %w", err)
+	}
+	if len(boms) == 0 || boms[0].ProductQty == 0 {
+		return nil, nil
+	}
+
+	var lines []struct {
+		Product    odoorpc.Many2one `json:"product_id"`
+		ProductQty float64          `json:"product_qty"`
+	}
+	opts = odoorpc.SearchOptions{Fields: []string{"product_id", "product_qty"}, Order: "sequence, id"}
+	if err := r.client.SearchRead(ctx, "mrp.bom.line", odoorpc.Domain{odoorpc.Cond("bom_id", "=", boms[0].ID)}, opts, &lines); err != nil {
+		return nil, fmt.Errorf("error al obtener los componentes del kit: %w", err)
+	}
+	ids := make([]int64, len(lines))
+	for i, line := range lines {
+		ids[i] = line.Product.ID
+	}
+	var stock []rpcProduct
+	if err := r.client.Read(ctx, "product.product", ids, []string{"name", "qty_available"}, stockContext(config), &stock); err != nil {
+		return nil, fmt.Errorf("error al obtener los componentes del kit: %w", err)
+	}
+	byID := make(map[int64]rpcProduct, len(stock))
+	for _, s := range stock {
+		byID[s.ID] = s
+	}
+
+	components := make([]model.KitComponent, 0, len(lines))
+	for _, line := range lines {
+		component := byID[line.Product.ID]
+		components = append(components, model.KitComponent{
+			ProductID: line.Product.ID,
+			Name:      string(component.Name),
+			Quantity:  line.ProductQty / boms[0].ProductQty,
+			Stock:     component.QtyAvailable,
+		})
+	}
+	return components, nil
+}
+
+// GetBestSelling ordena por la cantidad entregada a clientes (stock.move hechos hacia la
+// ubicación de clientes), igual que odooProductRepo.
+func (r *rpcProductRepo) GetBestSelling(ctx context.Context, limit int) ([]model.ProductDTO, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	// Los grupos llegan ordenados por cantidad, así que basta con leer el producto.
+	var groups []struct {
+		Product odoorpc.Many2one `json:"product_id"`
+	}
+	quantity := r.schema.moveQuantity()
+	domain := odoorpc.Domain{odoorpc.Cond("location_dest_id", "=", customerLocationID), odoorpc.Cond("state", "=", "done")}
+	if err := r.client.ReadGroup(ctx, "stock.move", domain, []string{quantity + ":sum"}, []string{"product_id"}, odoorpc.SearchOptions{Order: quantity + " desc"}, &groups); err != nil {
+		return nil, fmt.Errorf("ha ocurrido un error: %w", err)
+	}
+	ids := make([]int64, len(groups))
+	rank := make(map[int64]int, len(groups))
+	for i, g := range groups {
+		ids[i] = g.Product.ID
+		rank[g.Product.ID] = i
+	}
+
+	// Solo cuentan los productos visibles con stock, como en el listado.
+	var rows []rpcProduct
+	domain = append(inStockDomain(config), odoorpc.Cond("id", "in", nonNil(ids)))
+	if err := r.client.SearchRead(ctx, "product.product", domain, odoorpc.SearchOptions{Fields: rpcProductFields, Context: stockContext(config)}, &rows); err != nil {
+		return nil, fmt.Errorf("ha ocurrido un error: %w", err)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rank[rows[i].ID] < rank[rows[j].ID] })
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	products := r.toDTOs(rows)
+	if err := r.attachPrices(ctx, config, products, rows); err != nil {
+		return nil, err
+	}
+	if err := r.attachUnits(ctx, products, rows); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetVariants devuelve las variantes visibles con stock de la plantilla de productID.
+func (r *rpcProductRepo) GetVariants(ctx context.Context, productID int64) ([]model.ProductDTO, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	var product []rpcProduct
+	if err := r.client.Read(ctx, "product.product", []int64{productID}, []string{"product_tmpl_id"}, nil, &product); err != nil {
+		return nil, fmt.Errorf("error al obtener las variantes: %w", err)
+	}
+	if len(product) == 0 {
+		return []model.ProductDTO{}, nil
+	}
+	var rows []rpcProduct
+	domain := append(inStockDomain(config), odoorpc.Cond("product_tmpl_id", "=", product[0].Template.ID))
+	if err := r.client.SearchRead(ctx, "product.product", domain, odoorpc.SearchOptions{Fields: rpcProductFields, Order: "id", Context: stockContext(config)}, &rows); err != nil {
+		return nil, fmt.Errorf("error al obtener las variantes: %w", err)
+	}
+	products := r.toDTOs(rows)
+	if err := r.attachPrices(ctx, config, products, rows); err != nil {
+		return nil, err
+	}
+	if err := r.attachUnits(ctx, products, rows); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetCategorys lista las categorías con algún producto visible en su subárbol.
+func (r *rpcProductRepo) GetCategorys(ctx context.Context) ([]Category, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	var groups []struct {
+		Category odoorpc.Many2one `json:"categ_id"`
+	}
+	if err := r.client.ReadGroup(ctx, "product.product", visibility(config), []string{"categ_id"}, []string{"categ_id"}, odoorpc.SearchOptions{}, &groups); err != nil {
+		return nil, fmt.Errorf("error al obtener las categorías: %w", err)
+	}
+	ids := make([]int64, 0, len(groups))
+	for _, g := range groups {
+		if g.Category.ID != 0 {
+			ids = append(ids, g.Category.ID)
+		}
+	}
+
+	var rows []struct {
+		Name odoorpc.String `json:"name"`
+	}
+	domain := odoorpc.Domain{odoorpc.Cond("id", "parent_of", nonNil(ids))}
+	if err := r.client.SearchRead(ctx, "product.category", domain, odoorpc.SearchOptions{Fields: []string{"name"}}, &rows); err != nil {
+		return nil, fmt.Errorf("error al obtener las categorías: %w", err)
+	}
+	var categorys []Category
+	for _, row := range rows {
+		categorys = appendCategory(categorys, string(row.Name))
+	}
+	return categorys, nil
+}
+
+// rpcPricelistItem es una regla de product.pricelist.item.
+type rpcPricelistItem struct {
+	ID             int64            `json:"id"`
+	AppliedOn      odoorpc.String   `json:"applied_on"`
+	ComputePrice   odoorpc.String   `json:"compute_price"`
+	Base           odoorpc.String   `json:"base"`
+	FixedPrice     float64          `json:"fixed_price"`
+	PercentPrice   float64          `json:"percent_price"`
+	PriceDiscount  float64          `json:"price_discount"`
+	PriceSurcharge float64          `json:"price_surcharge"`
+	MinQuantity    float64          `json:"min_quantity"`
+	DateStart      odoorpc.Time     `json:"date_start"`
+	DateEnd        odoorpc.Time     `json:"date_end"`
+	Product        odoorpc.Many2one `json:"product_id"`
+	Template       odoorpc.Many2one `json:"product_tmpl_id"`
+	Category       odoorpc.Many2one `json:"categ_id"`
+}
+
+// price aplica la regla al precio de lista, con las mismas fórmulas que effectivePrice.
+func (item rpcPricelistItem) price(listPrice float64) float64 {
+	switch {
+	case item.ComputePrice == "fixed":
+		return item.FixedPrice
+	case item.ComputePrice == "percentage":
+		return listPrice * (1 - item.PercentPrice/100)
+	case item.Base == "list_price":
+		return listPrice*(1-item.PriceDiscount/100) + item.PriceSurcharge
+	}
+	return listPrice
+}
+
+// pricelistItems devuelve las reglas vigentes de la tarifa para una unidad.
+func (r *rpcProductRepo) pricelistItems(ctx context.Context, config ProductRepoConfig) ([]rpcPricelistItem, error) {
+	var items []rpcPricelistItem
+	now := time.Now().UTC()
+	domain := odoorpc.Domain{odoorpc.Cond("pricelist_id", "=", config.PricelistID), odoorpc.Cond("pricelist_id.active", "=", true), odoorpc.Cond("min_quantity", "<=", 1)}
+	opts := odoorpc.SearchOptions{Fields: []string{"applied_on", "compute_price", "base", "fixed_price", "percent_price", "price_discount", "price_surcharge", "min_quantity", "date_start", "date_end", "product_id", "product_tmpl_id", "categ_id"}}
+	if err := r.client.SearchRead(ctx, "product.pricelist.item", domain, opts, &items); err != nil {
+		return nil, fmt.Errorf("error en la consulta: %w", err)
+	}
+	active := items[:0]
+	for _, item := range items {
+		if (item.DateStart.IsZero() || !item.DateStart.After(now)) && (item.DateEnd.IsZero() || !item.DateEnd.Before(now)) {
+			active = append(active, item)
+		}
+	}
+	return active, nil
+}
+
+// GetOnSale calcula en el backend el precio de la tarifa activa para los productos con
+// stock y devuelve los que quedan por debajo de list_price, ordenados por descuento.
+// Las reglas se eligen como en pricelistItem: variante, plantilla, categoría más específica, global.
+func (r *rpcProductRepo) GetOnSale(ctx context.Context, offset, limit int, orderValue string) (*model.ProductsResult, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	active, err := r.pricelistItems(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.ProductsResult{Products: []model.ProductDTO{}}
+	if len(active) == 0 {
+		return result, nil
+	}
+
+	fields := []string{"name", "product_tmpl_id", "categ_id", "list_price", "qty_available", "uom_id"}
+	var rows []rpcProduct
+	if err := r.client.SearchRead(ctx, "product.product", inStockDomain(config), odoorpc.SearchOptions{Fields: fields, Context: stockContext(config)}, &rows); err != nil {
+		return nil, fmt.Errorf("error en la consulta: %w", err)
+	}
+	paths, err := r.categoryPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type priced struct {
+		row      rpcProduct
+		price    float64
+		discount float64
+		dateEnd  *time.Time
+	}
+	var sale []priced
+	for _, row := range rows {
+		item, ok := pickPricelistItem(active, row, paths)
+		if !ok || row.ListPrice <= 0 {
+			continue
+		}
+		price := item.price(row.ListPrice)
+		if price >= row.ListPrice {
+			continue
+		}
+		discount := math.Round((1-price/row.ListPrice)*100*100) / 100
+		sale = append(sale, priced{row: row, price: price, discount: discount, dateEnd: item.DateEnd.Ptr()})
+	}
+	asc := strings.ToLower(orderValue) == "asc"
+	sort.Slice(sale, func(i, j int) bool {
+		if sale[i].discount != sale[j].discount {
+			return (sale[i].discount < sale[j].discount) == asc
+		}
+		return sale[i].row.ID < sale[j].row.ID
+	})
+
+	result.Total = uint(len(sale))
+	if offset >= len(sale) {
+		return result, nil
+	}
+	sale = sale[offset:]
+	if limit > 0 && len(sale) > limit {
+		sale = sale[:limit]
+	}
+
+	// Las imágenes solo se piden para la página.
+	page := make([]rpcProduct, len(sale))
+	ids := make([]int64, len(sale))
+	for i, p := range sale {
+		page[i], ids[i] = p.row, p.row.ID
+	}
+	var images []rpcProduct
+	if err := r.client.Read(ctx, "product.product", ids, []string{"image_1920"}, nil, &images); err != nil {
+		return nil, fmt.Errorf("error al obtener las imágenes: %w", err)
+	}
+	imageByID := make(map[int64]odoorpc.String, len(images))
+	for _, image := range images {
+		imageByID[image.ID] = image.Image
+	}
+	for i := range page {
+		page[i].Image = imageByID[page[i].ID]
+	}
+
+	result.Products = r.toDTOs(page)
+	for i, p := range sale {
+		result.Products[i].Price = p.price
+		result.Products[i].Discount = p.discount
+		result.Products[i].SaleEndDate = p.dateEnd
+	}
+	if err := r.attachUnits(ctx, result.Products, page); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// categoryPaths devuelve el parent_path de cada categoría, para saber si una regla de
+// categoría alcanza a un producto de una subcategoría.
+func (r *rpcProductRepo) categoryPaths(ctx context.Context) (map[int64]string, error) {
+	var categories []struct {
+		ID         int64          `json:"id"`
+		ParentPath odoorpc.String `json:"parent_path"`
+	}
+	if err := r.client.SearchRead(ctx, "product.category", nil, odoorpc.SearchOptions{Fields: []string{"parent_path"}}, &categories); err != nil {
+		return nil, fmt.Errorf("error al obtener las categorías: %w", err)
+	}
+	paths := make(map[int64]string, len(categories))
+	for _, c := range categories {
+		paths[c.ID] = string(c.ParentPath)
+	}
+	return paths, nil
+}
+
+// pickPricelistItem elige la regla aplicable más específica para el producto.
+func pickPricelistItem(items []rpcPricelistItem, product rpcProduct, paths map[int64]string) (rpcPricelistItem, bool) {
+	var (
+		best     rpcPricelistItem
+		bestPath string
+		found    bool
+	)
+	productPath := paths[product.Category.ID]
+	for _, item := range items {
+		var path string
+		switch item.AppliedOn {
+		case "3_global":
+		case "2_product_category":
+			path = paths[item.Category.ID]
+			if path == "" || !strings.HasPrefix(productPath, path) {
+				continue
+			}
+		case "1_product":
+			if item.Template.ID != product.Template.ID {
+				continue
+			}
+		case "0_product_variant":
+			if item.Product.ID != product.ID {
+				continue
+			}
+		default:
+			continue
+		}
+		if !found || betterItem(item, path, best, bestPath) {
+			best, bestPath, found = item, path, true
+		}
+	}
+	return best, found
+}
+
+// betterItem ordena como pricelistItem: applied_on, min_quantity desc, categoría más
+// profunda primero e id desc.
+func betterItem(a rpcPricelistItem, aPath string, b rpcPricelistItem, bPath string) bool {
+	if a.AppliedOn != b.AppliedOn {
+		return a.AppliedOn < b.AppliedOn
+	}
+	if a.MinQuantity != b.MinQuantity {
+		return a.MinQuantity > b.MinQuantity
+	}
+	if aPath != bPath {
+		return aPath > bPath
+	}
+	return a.ID > b.ID
+}
+
+// rpcPrices calcula con las reglas de la tarifa el precio de una unidad, redondeado a
+// céntimos, como pricelistPrices en SQL. Es el precio del catálogo y el que exige check.
+func rpcPrices(items []rpcPricelistItem, paths map[int64]string, rows []rpcProduct) map[int64]pricelistPrice {
+	prices := make(map[int64]pricelistPrice, len(rows))
+	for _, row := range rows {
+		p := pricelistPrice{price: row.ListPrice}
+		if item, ok := pickPricelistItem(items, row, paths); ok {
+			p.price, p.dateEnd = item.price(row.ListPrice), item.DateEnd.Ptr()
+		}
+		p.price = math.Round(p.price*100) / 100
+		prices[row.ID] = p
+	}
+	return prices
+}
+
+// attachPrices aplica a los productos los precios de la tarifa de la tienda.
+func (r *rpcProductRepo) attachPrices(ctx context.Context, config ProductRepoConfig, products []model.ProductDTO, rows []rpcProduct) error {
+	if len(products) == 0 {
+		return nil
+	}
+	items, err := r.pricelistItems(ctx, config)
+	if err != nil {
+		return err
+	}
+	paths, err := r.categoryPaths(ctx)
+	if err != nil {
+		return err
+	}
+	applyPricelist(products, rpcPrices(items, paths, rows))
+	return nil
+}
+
+// GetAttributes lista los atributos y los valores usados por algún producto visible.
+func (r *rpcProductRepo) GetAttributes(ctx context.Context) ([]model.Attribute, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	var templates []struct {
+		Template odoorpc.Many2one `json:"product_tmpl_id"`
+	}
+	if err := r.client.ReadGroup(ctx, "product.product", visibility(config), []string{"product_tmpl_id"}, []string{"product_tmpl_id"}, odoorpc.SearchOptions{}, &templates); err != nil {
+		return nil, fmt.Errorf("error al obtener los atributos: %w", err)
+	}
+	tmplIDs := make([]int64, len(templates))
+	for i, t := range templates {
+		tmplIDs[i] = t.Template.ID
+	}
+
+	var ptavs []struct {
+		Attribute odoorpc.Many2one `json:"attribute_id"`
+		Value     odoorpc.Many2one `json:"product_attribute_value_id"`
+	}
+	domain := odoorpc.Domain{odoorpc.Cond("ptav_active", "=", true), odoorpc.Cond("product_tmpl_id", "in", nonNil(tmplIDs))}
+	if err := r.client.SearchRead(ctx, "product.template.attribute.value", domain, odoorpc.SearchOptions{Fields: []string{"attribute_id", "product_attribute_value_id"}}, &ptavs); err != nil {
+		return nil, fmt.Errorf("error al obtener los atributos: %w", err)
+	}
+	attrIDs, valueIDs := []int64{}, []int64{}
+	seen := map[int64]bool{}
+	for _, ptav := range ptavs {
+		if !seen[ptav.Value.ID] {
+			seen[ptav.Value.ID] = true
+			valueIDs = append(valueIDs, ptav.Value.ID)
+		}
+		if !seen[-ptav.Attribute.ID] {
+			seen[-ptav.Attribute.ID] = true
+			attrIDs = append(attrIDs, ptav.Attribute.ID)
+		}
+	}
+
+	var attrs []struct {
+		ID   int64          `json:"id"`
+		Name odoorpc.String `json:"name"`
+	}
+	if err := r.client.SearchRead(ctx, "product.attribute", odoorpc.Domain{odoorpc.Cond("id", "in", attrIDs)}, odoorpc.SearchOptions{Fields: []string{"name"}, Order: "sequence, id"}, &attrs); err != nil {
+		return nil, fmt.Errorf("error al obtener los atributos: %w", err)
+	}
+	var values []struct {
+		ID        int64            `json:"id"`
+		Name      odoorpc.String   `json:"name"`
+		Attribute odoorpc.Many2one `json:"attribute_id"`
+	}
+	if err := r.client.SearchRead(ctx, "product.attribute.value", odoorpc.Domain{odoorpc.Cond("id", "in", valueIDs)}, odoorpc.SearchOptions{Fields: []string{"name", "attribute_id"}, Order: "sequence, id"}, &values); err != nil {
+		return nil, fmt.Errorf("error al obtener los atributos: %w", err)
+	}
+
+	attributes := make([]model.Attribute, 0, len(attrs))
+	index := make(map[int64]int, len(attrs))
+	for _, a := range attrs {
+		index[a.ID] = len(attributes)
+		attributes = append(attributes, model.Attribute{ID: a.ID, Name: string(a.Name)})
+	}
+	for _, v := range values {
+		if i, ok := index[v.Attribute.ID]; ok {
+			attributes[i].Values = append(attributes[i].Values, model.AttributeValue{ID: v.ID, Name: string(v.Name)})
+		}
+	}
+	return attributes, nil
+}
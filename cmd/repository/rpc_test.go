@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc/odoorpctest"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// newFakeOdoo arranca un Odoo simulado con el catálogo y las ventas de prueba.
+func newFakeOdoo(t *testing.T) (*odoorpc.Client, *odoorpctest.FakeServer) {
+	t.Helper()
+	fake := odoorpctest.NewFakeServer("odoo", "admin", "admin")
+	odoorpctest.SeedCatalog(fake)
+	odoorpctest.SeedSales(fake)
+	fake.Start()
+	t.Cleanup(fake.Close)
+	return odoorpc.NewClient(fake.Config()), fake
+}
+
+func newFakeProductRepo(t *testing.T) ProductRepo {
+	t.Helper()
+	client, _ := newFakeOdoo(t)
+	return NewRPCProductRepo(client, ProductRepoConfig{PricelistID: 1, Visibility: VisibilityPolicy{Active: true, SaleOk: true}})
+}
+
+func productIDs(products []model.ProductDTO) []uint64 {
+	ids := make([]uint64, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func TestRPCProductRepoGetFiltered(t *testing.T) {
+	repo := newFakeProductRepo(t)
+	ctx := context.Background()
+	int64p := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name       string
+		categID    *int64
+		minPrice   *int64
+		categorys  []string
+		search     string
+		order      string
+		attributes map[string][]string
+		want       []uint64
+	}{
+		// El labial rosa no tiene stock y el material de oficina no se vende.
+		{name: "all", want: []uint64{1, 2, 3, 5, 6}},
+		{name: "category id", categID: int64p(3), want: []uint64{1, 2}},
+		{name: "category name", categorys: []string{"Labiales", " "}, want: []uint64{3}},
+		{name: "min price", minPrice: int64p(10), order: "price_desc", want: []uint64{5, 2, 1}},
+		{name: "name", search: "crema", order: "name_desc", want: []uint64{2, 1}},
+		{name: "attribute", attributes: map[string][]string{"Color": {"Rojo", "Rosa"}}, want: []uint64{3}},
+		{name: "unknown order", order: "id; drop", want: []uint64{1, 2, 3, 5, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.GetFiltered(ctx, 0, 10, tt.categID, tt.minPrice, nil, tt.categorys, tt.search, tt.order, tt.attributes)
+			if err != nil {
+				t.Fatalf("GetFiltered: %v", err)
+			}
+			if got := productIDs(result.Products); !reflect.DeepEqual(got, tt.want) || result.Total != uint(len(tt.want)) {
+				t.Errorf("GetFiltered = %v (total %d); want %v", got, result.Total, tt.want)
+			}
+		})
+	}
+
+	page, err := repo.GetFiltered(ctx, 2, 2, nil, nil, nil, nil, "", "", nil)
+	if err != nil {
+		t.Fatalf("GetFiltered page: %v", err)
+	}
+	if got := productIDs(page.Products); !reflect.DeepEqual(got, []uint64{3, 5}) || page.Total != 5 {
+		t.Errorf("GetFiltered(2, 2) = %v (total %d); want [3 5] of 5", got, page.Total)
+	}
+	past, err := repo.GetFiltered(ctx, 10, 2, nil, nil, nil, nil, "", "", nil)
+	if err != nil || len(past.Products) != 0 || past.Total != 5 {
+		t.Errorf("GetFiltered past the end = %+v, %v; want no products of 5", past, err)
+	}
+}
+
+func TestRPCProductRepoGetByID(t *testing.T) {
+	repo := newFakeProductRepo(t)
+	ctx := context.Background()
+
+	cream, err := repo.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetByID(1): %v", err)
+	}
+	if cream.Name != "Crema hidratante" || cream.Category != "Cremas" || cream.Stock != 20 || cream.IsKit {
+		t.Errorf("GetByID(1) = %+v", cream)
+	}
+	// La ficha muestra el precio de la tarifa, no el de lista.
+	if cream.Price != 11.25 || cream.OriginalPrice != 12.5 || cream.Discount != 10 {
+		t.Errorf("GetByID(1) price = %v (list %v, -%v%%); want 11.25 (list 12.5, -10%%)", cream.Price, cream.OriginalPrice, cream.Discount)
+	}
+	if cream.Uom == nil || cream.Uom.Name != "Unidades" || len(cream.Packagings) != 1 || cream.Packagings[0].Qty != 12 {
+		t.Errorf("GetByID(1) units = %+v %+v; want Unidades and a box of 12", cream.Uom, cream.Packagings)
+	}
+
+	kit, err := repo.GetByID(ctx, 5)
+	if err != nil {
+		t.Fatalf("GetByID(5): %v", err)
+	}
+	want := []model.KitComponent{
+		{ProductID: 1, Name: "Crema hidratante", Quantity: 1, Stock: 20},
+		{ProductID: 2, Name: "Crema solar SPF50", Quantity: 1, Stock: 5},
+	}
+	if !kit.IsKit || !reflect.DeepEqual(kit.Components, want) {
+		t.Errorf("GetByID(5) components = %+v; want %+v", kit.Components, want)
+	}
+
+	// El stock se redondea a la precisión de la unidad (kg, 0.001).
+	soap, err := repo.GetByID(ctx, 6)
+	if err != nil {
+		t.Fatalf("GetByID(6): %v", err)
+	}
+	if soap.Stock != 10.25 || soap.Uom == nil || soap.Uom.Name != "kg" {
+		t.Errorf("GetByID(6) = stock %v uom %+v; want 10.25 kg", soap.Stock, soap.Uom)
+	}
+
+	for _, id := range []int64{4, 7, 99} {
+		if product, err := repo.GetByID(ctx, id); err == nil {
+			t.Errorf("GetByID(%d) = %+v; want an error", id, product)
+		}
+	}
+}
+
+func TestRPCProductRepoGetOnSale(t *testing.T) {
+	repo := newFakeProductRepo(t)
+	ctx := context.Background()
+
+	result, err := repo.GetOnSale(ctx, 0, 10, "")
+	if err != nil {
+		t.Fatalf("GetOnSale: %v", err)
+	}
+	// El labial rojo tiene precio fijo (7 -> 5) y las cremas un 10% por categoría.
+	if got := productIDs(result.Products); !reflect.DeepEqual(got, []uint64{3, 1, 2}) || result.Total != 3 {
+		t.Fatalf("GetOnSale = %v (total %d); want [3 1 2]", got, result.Total)
+	}
+	lipstick, cream := result.Products[0], result.Products[1]
+	if lipstick.Price != 5 || lipstick.OriginalPrice != 7 || lipstick.Discount != 28.57 || lipstick.SaleEndDate != nil {
+		t.Errorf("GetOnSale lipstick = %+v", lipstick)
+	}
+	if cream.Price != 11.25 || cream.Discount != 10 || cream.SaleEndDate == nil || cream.SaleEndDate.Year() != 2099 {
+		t.Errorf("GetOnSale cream = %+v", cream)
+	}
+
+	asc, err := repo.GetOnSale(ctx, 1, 1, "asc")
+	if err != nil {
+		t.Fatalf("GetOnSale asc: %v", err)
+	}
+	if got := productIDs(asc.Products); !reflect.DeepEqual(got, []uint64{2}) || asc.Total != 3 {
+		t.Errorf("GetOnSale(1, 1, asc) = %v (total %d); want [2] of 3", got, asc.Total)
+	}
+}
+
+func TestRPCProductRepoGetBestSelling(t *testing.T) {
+	repo := newFakeProductRepo(t)
+
+	// Solo cuentan las entregas a clientes: el movimiento interno de la crema solar no suma.
+	products, err := repo.GetBestSelling(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("GetBestSelling: %v", err)
+	}
+	if got := productIDs(products); !reflect.DeepEqual(got, []uint64{3, 1}) {
+		t.Errorf("GetBestSelling(2) = %v; want [3 1]", got)
+	}
+}
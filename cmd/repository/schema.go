@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/db"
+)
+
+// Versiones mayores de Odoo cuyo esquema conocen las consultas del catálogo.
+const (
+	minOdooVersion     = 15
+	maxOdooVersion     = 17
+	defaultOdooVersion = 16
+)
+
+// schema reúne las diferencias del esquema de Odoo entre versiones que afectan a las
+// consultas. El resto del SQL es común a las versiones soportadas.
+type schema interface {
+	// name devuelve el campo name traducible del modelo (alias de su tabla) como un jsonb
+	// {"lang": "texto"}, que es como lo guarda Odoo 16 en adelante.
+	name(alias, model string) string
+	// moveQuantity es la columna (y el campo) de stock.move con la cantidad hecha.
+	moveQuantity() string
+	// pricelistEnd es el fin de vigencia de la regla de tarifa (alias i) como timestamp.
+	pricelistEnd() string
+}
+
+// odoo15 guarda los textos traducibles en varchar (en_US) y sus traducciones en
+// ir_translation.
+type odoo15 struct{}
+
+func (odoo15) name(alias, model string) string {
+	return fmt.Sprintf(`(jsonb_build_object('en_US', %s.name) || COALESCE((SELECT jsonb_object_agg(t.lang, t.value) FROM ir_translation t WHERE t.name = '%s,name' AND t.res_id = %s.id AND t.type = 'model' AND t.value <> ''), '{}'::jsonb))`, alias, model, alias)
+}
+
+func (odoo15) moveQuantity() string { return "quantity_done" }
+
+// date_start y date_end son Datetime desde Odoo 14, como en la ruta JSON-RPC.
+func (odoo15) pricelistEnd() string { return "i.date_end" }
+
+// odoo16 guarda los textos traducibles directamente en jsonb.
+type odoo16 struct{}
+
+func (odoo16) name(alias, model string) string { return alias + ".name" }
+
+func (odoo16) moveQuantity() string { return "quantity_done" }
+
+func (odoo16) pricelistEnd() string { return "i.date_end" }
+
+// odoo17 renombra stock_move.quantity_done a quantity.
+type odoo17 struct{ odoo16 }
+
+func (odoo17) moveQuantity() string { return "quantity" }
+
+// schemaFor devuelve el esquema de la versión mayor dada; 0 es la versión por defecto.
+func schemaFor(version int) schema {
+	if version == 0 {
+		version = defaultOdooVersion
+	}
+	switch {
+	case version <= 15:
+		return odoo15{}
+	case version == 16:
+		return odoo16{}
+	default:
+		return odoo17{}
+	}
+}
+
+// CheckOdooVersion devuelve un error si las consultas no soportan la versión mayor dada.
+func CheckOdooVersion(version int) error {
+	if version < minOdooVersion || version > maxOdooVersion {
+		return fmt.Errorf("unsupported Odoo version %d (supported: %d to %d)", version, minOdooVersion, maxOdooVersion)
+	}
+	return nil
+}
+
+// ParseOdooVersion extrae la versión mayor de cadenas como "16.0", "17.0+e",
+// "saas~16.3" o "16.0.1.3" (latest_version de ir_module_module).
+func ParseOdooVersion(version string) (int, error) {
+	major, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(version), "saas~"), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Odoo version %q", version)
+	}
+	return n, nil
+}
+
+// DetectOdooVersion lee la versión mayor de Odoo de la base de datos a partir del
+// módulo base instalado.
+func DetectOdooVersion(ctx context.Context, d db.Reader) (int, error) {
+	var version string
+	if err := d.QueryRowContext(ctx, "SELECT latest_version FROM ir_module_module WHERE name = 'base' AND state = 'installed';").Scan(&version); err != nil {
+		return 0, fmt.Errorf("error detecting the Odoo version: %w", err)
+	}
+	return ParseOdooVersion(version)
+}
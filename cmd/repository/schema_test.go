@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOdooVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"16.0", 16},
+		{"17.0+e", 17},
+		{"saas~16.3", 16},
+		{"16.0.1.3", 16},
+		{" 15.0 ", 15},
+	}
+	for _, tt := range tests {
+		if got, err := ParseOdooVersion(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseOdooVersion(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "master", "v16.0"} {
+		if got, err := ParseOdooVersion(in); err == nil {
+			t.Errorf("ParseOdooVersion(%q) = %d; want an error", in, got)
+		}
+	}
+}
+
+func TestSchemaFor(t *testing.T) {
+	tests := []struct {
+		version     int
+		translation bool
+		quantity    string
+	}{
+		{version: 0, quantity: "quantity_done"},
+		{version: 15, translation: true, quantity: "quantity_done"},
+		{version: 16, quantity: "quantity_done"},
+		{version: 17, quantity: "quantity"},
+	}
+	for _, tt := range tests {
+		s := schemaFor(tt.version)
+		name := s.name("pt", "product.template")
+		if got := strings.Contains(name, "ir_translation"); got != tt.translation || (!tt.translation && name != "pt.name") {
+			t.Errorf("schemaFor(%d).name = %s", tt.version, name)
+		}
+		if got := s.moveQuantity(); got != tt.quantity {
+			t.Errorf("schemaFor(%d).moveQuantity = %s; want %s", tt.version, got, tt.quantity)
+		}
+		// date_end es Datetime en todas las versiones: se compara tal cual.
+		if got := s.pricelistEnd(); got != "i.date_end" {
+			t.Errorf("schemaFor(%d).pricelistEnd = %s; want i.date_end", tt.version, got)
+		}
+	}
+}
+
+func TestCheckOdooVersion(t *testing.T) {
+	for version := minOdooVersion; version <= maxOdooVersion; version++ {
+		if err := CheckOdooVersion(version); err != nil {
+			t.Errorf("CheckOdooVersion(%d) = %v", version, err)
+		}
+	}
+	for _, version := range []int{14, 18} {
+		if err := CheckOdooVersion(version); err == nil {
+			t.Errorf("CheckOdooVersion(%d) = nil; want an error", version)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/db"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrShopNotFound indica que la tienda no existe o no es de la tienda online de la petición.
+	ErrShopNotFound = errors.New("tienda no encontrada")
+	// ErrProductNotFound indica que el producto no existe o no es visible en el catálogo.
+	ErrProductNotFound = errors.New("producto no encontrado")
+)
+
+// Tipos de tienda física (model.Shop.Kind).
+const (
+	ShopKindPOS       = "shop"
+	ShopKindWarehouse = "warehouse"
+)
+
+// ShopRepo lista las tiendas físicas: los puntos de venta activos de Odoo (pos_config) con
+// la ubicación de origen de su tipo de operación, que es de donde sale lo que venden.
+type ShopRepo interface {
+	GetShops(ctx context.Context) ([]model.Shop, error)
+	GetShop(ctx context.Context, id int64) (*model.Shop, error)
+	// GetAvailability devuelve el stock libre del producto en cada punto de venta y en cada
+	// almacén cuya ubicación no use ya un punto de venta.
+	GetAvailability(ctx context.Context, productID int64) ([]model.ShopAvailability, error)
+}
+
+// ShopHours son los horarios de las tiendas, que Odoo no guarda. La clave es el tipo y el id
+// de la tienda ("shop/3", "warehouse/1") y el valor el texto que se muestra.
+type ShopHours map[string]string
+
+// LoadShopHours lee los horarios de un fichero JSON; path vacío no carga ninguno.
+func LoadShopHours(path string) (ShopHours, error) {
+	hours := ShopHours{}
+	if path == "" {
+		return hours, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading shop hours file: %w", err)
+	}
+	if err := json.Unmarshal(content, &hours); err != nil {
+		return nil, fmt.Errorf("error parsing shop hours file: %w", err)
+	}
+	return hours, nil
+}
+
+func (h ShopHours) of(shop model.Shop) string {
+	return h[shop.Kind+"/"+strconv.FormatInt(shop.ID, 10)]
+}
+
+type odooShopRepo struct {
+	DB     db.Reader
+	config ProductRepoConfig
+	hours  ShopHours
+}
+
+// NewShopRepo construye el repositorio de tiendas físicas sobre la base de Odoo.
+func NewShopRepo(d db.Reader, config ProductRepoConfig, hours ShopHours) ShopRepo {
+	return &odooShopRepo{DB: d, config: config, hours: hours}
+}
+
+// shopAddress da la dirección del contacto del almacén (alias w) en una línea.
+const shopAddress = `(SELECT concat_ws(', ', NULLIF(rp.street, ''), NULLIF(rp.street2, ''), NULLIF(rp.city, ''), NULLIF(rcs.name, ''))
+		FROM res_partner rp LEFT JOIN res_country_state rcs ON rcs.id = rp.state_id WHERE rp.id = w.partner_id)`
+
+// shopsQuery devuelve los puntos de venta de la compañía $1 (0 = todas).
+const shopsQuery = `SELECT 'shop' AS kind, pc.id, pc.name, COALESCE(` + shopAddress + `, '') AS address, spt.default_location_src_id AS location_id, pc.company_id
+	FROM pos_config pc
+	INNER JOIN stock_picking_type spt ON spt.id = pc.picking_type_id
+	LEFT JOIN stock_warehouse w ON w.id = spt.warehouse_id
+	WHERE pc.active AND spt.default_location_src_id IS NOT NULL AND ($1 = 0 OR pc.company_id = $1)`
+
+// warehousesQuery devuelve los almacenes de la compañía $1 cuya ubicación de stock no
+// es la de ningún punto de venta activo, para no repetir la misma tienda.
+const warehousesQuery = `SELECT 'warehouse' AS kind, w.id, w.name, COALESCE(` + shopAddress + `, '') AS address, w.lot_stock_id AS location_id, w.company_id
+	FROM stock_warehouse w
+	WHERE w.active AND ($1 = 0 OR w.company_id = $1) AND NOT EXISTS (
+		SELECT 1 FROM pos_config pc INNER JOIN stock_picking_type spt ON spt.id = pc.picking_type_id
+		WHERE pc.active AND spt.default_location_src_id = w.lot_stock_id)`
+
+func (r *odooShopRepo) scanShop(row interface{ Scan(...any) error }) (model.Shop, error) {
+	var shop model.Shop
+	err := row.Scan(&shop.Kind, &shop.ID, &shop.Name, &shop.Address, &shop.LocationID, &shop.CompanyID)
+	shop.OpeningHours = r.hours.of(shop)
+	return shop, err
+}
+
+func (r *odooShopRepo) GetShops(ctx context.Context) ([]model.Shop, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	rows, err := r.DB.QueryContext(ctx, shopsQuery+" ORDER BY pc.sequence, pc.name", config.Visibility.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las tiendas: %w", err)
+	}
+	defer rows.Close()
+
+	shops := []model.Shop{}
+	for rows.Next() {
+		shop, err := r.scanShop(rows)
+		if err != nil {
+			log.Printf("error al escanear tienda: %v", err)
+			continue
+		}
+		shops = append(shops, shop)
+	}
+	return shops, rows.Err()
+}
+
+func (r *odooShopRepo) GetShop(ctx context.Context, id int64) (*model.Shop, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	shop, err := r.scanShop(r.DB.QueryRowContext(ctx, shopsQuery+" AND pc.id = $2", config.Visibility.CompanyID, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShopNotFound
+		}
+		return nil, fmt.Errorf("error al obtener la tienda: %w", err)
+	}
+	return &shop, nil
+}
+
+func (r *odooShopRepo) GetAvailability(ctx context.Context, productID int64) ([]model.ShopAvailability, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	config := r.config.forStore(ctx)
+
+	var visible bool
+	query := "SELECT EXISTS (SELECT 1 FROM product_product WHERE id = $1 AND " + config.Visibility.productFilter("id") + ")"
+	if err := r.DB.QueryRowContext(ctx, query, productID).Scan(&visible); err != nil {
+		return nil, fmt.Errorf("error al obtener el producto %d: %w", productID, err)
+	}
+	if !visible {
+		return nil, ErrProductNotFound
+	}
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT * FROM ("+shopsQuery+" UNION ALL "+warehousesQuery+") shops ORDER BY kind, name", config.Visibility.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las tiendas: %w", err)
+	}
+	defer rows.Close()
+
+	var shops []model.ShopAvailability
+	var locations []int64
+	for rows.Next() {
+		shop, err := r.scanShop(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear tienda: %w", err)
+		}
+		shops = append(shops, model.ShopAvailability{Shop: shop})
+		locations = append(locations, shop.LocationID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al obtener las tiendas: %w", err)
+	}
+	rows.Close()
+	if len(shops) == 0 {
+		return []model.ShopAvailability{}, nil
+	}
+
+	rows, err = r.DB.QueryContext(ctx, "SELECT location_id, SUM(quantity) FROM "+stockByLocation(config)+" quants WHERE product_id = $1 GROUP BY location_id",
+		productID, pq.Array(locations))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el stock por tienda: %w", err)
+	}
+	defer rows.Close()
+	stock := make(map[int64]float64, len(locations))
+	for rows.Next() {
+		var location int64
+		var qty float64
+		if err := rows.Scan(&location, &qty); err != nil {
+			return nil, fmt.Errorf("error al obtener el stock por tienda: %w", err)
+		}
+		stock[location] = qty
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al obtener el stock por tienda: %w", err)
+	}
+	for i := range shops {
+		shops[i].Quantity = max(stock[shops[i].LocationID], 0)
+	}
+	return shops, nil
+}
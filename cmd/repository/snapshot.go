@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/db"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/query"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/lib/pq"
+)
+
+// SnapshotRepo sirve los listados del catálogo desde catalog_snapshot
+// (cmd/internal/db/snapshot.sql), una tabla propia del backend con una fila por producto
+// vendible. El detalle, los más vendidos, las variantes y los atributos se delegan al
+// ProductRepo en vivo, igual que todo mientras la primera carga completa no ha terminado
+// y los listados de las tiendas con otra compañía, ubicación o tarifa que la configuración.
+type SnapshotRepo struct {
+	ProductRepo
+	DB     *sql.DB
+	reader db.Reader
+	config ProductRepoConfig
+	units  *odooProductRepo
+	ready  atomic.Bool
+	// mu serializa las escrituras de la instantánea y protege since y pricelist.
+	mu    sync.Mutex
+	since time.Time
+	// pricelist es la firma de la tarifa (pricelistSignature) en la última carga.
+	pricelist string
+}
+
+// NewSnapshotRepo construye el repositorio sobre el ProductRepo en vivo live.
+// La instantánea se escribe en el primario d y los listados se leen de reader.
+func NewSnapshotRepo(d *sql.DB, reader db.Reader, config ProductRepoConfig, live ProductRepo) *SnapshotRepo {
+	return &SnapshotRepo{ProductRepo: live, DB: d, reader: reader, config: config, units: &odooProductRepo{DB: reader, config: config, schema: schemaFor(config.OdooVersion)}}
+}
+
+// Ready indica si la primera carga completa terminó y los listados ya salen de la instantánea.
+func (r *SnapshotRepo) Ready() bool {
+	return r.ready.Load()
+}
+
+// serves indica si la instantánea vale para la petición: ya está cargada y la tienda usa la
+// misma compañía, ubicación y tarifa con las que se calcula.
+func (r *SnapshotRepo) serves(ctx context.Context) bool {
+	if !r.ready.Load() {
+		return false
+	}
+	config := r.config.forStore(ctx)
+	return config.Visibility == r.config.Visibility && config.location() == r.config.location() && config.PricelistID == r.config.PricelistID
+}
+
+// snapshotOrders es la lista blanca de órdenes, con las mismas claves que catalogOrders.
+var snapshotOrders = map[string]string{
+	"":           "s.product_id",
+	"asc":        "s.list_price ASC, s.product_id",
+	"desc":       "s.list_price DESC, s.product_id",
+	"price_asc":  "s.list_price ASC, s.product_id",
+	"price_desc": "s.list_price DESC, s.product_id",
+	"name_asc":   translated("s.name") + " ASC, s.product_id",
+	"name_desc":  translated("s.name") + " DESC, s.product_id",
+	"stock_desc": "s.stock DESC, s.product_id",
+	"newest":     "s.product_id DESC",
+}
+
+func (r *SnapshotRepo) GetAll(ctx context.Context, offset, limit int) (*model.ProductsResult, error) {
+	return r.GetFiltered(ctx, offset, limit, nil, nil, nil, nil, "", "", nil)
+}
+
+func (r *SnapshotRepo) GetRelated(ctx context.Context, category, name string, offset, limit *int) (*model.ProductsResult, error) {
+	return r.GetFiltered(ctx, *offset, *limit, nil, nil, nil, []string{category}, name, "", nil)
+}
+
+func (r *SnapshotRepo) GetFiltered(ctx context.Context, offset, limit int, categID, minPrice, maxPrice *int64, categorys []string, name, orderValue string, attributes map[string][]string) (*model.ProductsResult, error) {
+	if !r.serves(ctx) {
+		return r.ProductRepo.GetFiltered(ctx, offset, limit, categID, minPrice, maxPrice, categorys, name, orderValue, attributes)
+	}
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	q := query.New().Apply(
+		func(b *query.Builder) { b.Where("s.stock > 0") },
+		query.Equal("s.category_id", categID),
+		query.Between("s.list_price", minPrice, maxPrice),
+		query.ContainsAny("s.name_search", []string{name}),
+		query.ContainsAny("s.category_name", categorys),
+		attributeFilter(r.units.schema, "s.product_id", attributes),
+	).OrderBy(orderValue, snapshotOrders).Page(offset, limit)
+
+	return r.list(ctx, "SELECT s.product_id, s.name, s.category_name, s.list_price, s.price, CASE WHEN s.price < s.list_price THEN ROUND((1 - s.price / s.list_price) * 100, 2) END, CASE WHEN s.price < s.list_price THEN s.sale_end END, s.stock, s.image_ids, COUNT(*) OVER() FROM catalog_snapshot s"+q.WhereClause()+q.OrderClause()+q.PageClause()+";", q.Args()...)
+}
+
+func (r *SnapshotRepo) GetOnSale(ctx context.Context, offset, limit int, orderValue string) (*model.ProductsResult, error) {
+	if !r.serves(ctx) {
+		return r.ProductRepo.GetOnSale(ctx, offset, limit, orderValue)
+	}
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	q := query.New().Where("s.stock > 0 AND s.list_price > 0 AND s.price < s.list_price").OrderBy(orderValue, map[string]string{
+		"":    "discount DESC, s.product_id",
+		"asc": "discount ASC, s.product_id",
+	}).Page(offset, limit)
+
+	return r.list(ctx, "SELECT s.product_id, s.name, s.category_name, s.list_price, s.price, ROUND((1 - s.price / s.list_price) * 100, 2) AS discount, s.sale_end, s.stock, s.image_ids, COUNT(*) OVER() FROM catalog_snapshot s"+q.WhereClause()+q.OrderClause()+q.PageClause()+";", q.Args()...)
+}
+
+func (r *SnapshotRepo) GetCategorys(ctx context.Context) ([]Category, error) {
+	if !r.serves(ctx) {
+		return r.ProductRepo.GetCategorys(ctx)
+	}
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+
+	// Igual que en vivo, una categoría se lista si tiene productos visibles en su subárbol.
+	rows, err := r.reader.QueryContext(ctx, `SELECT pc.name FROM product_category pc
+	WHERE EXISTS (SELECT 1 FROM catalog_snapshot s INNER JOIN product_category sc ON sc.id = s.category_id WHERE sc.parent_path LIKE pc.parent_path || '%');`)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las categorías: %w", err)
+	}
+	defer rows.Close()
+	var categorys []Category
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Printf("error al leer el valor de category: %v", err)
+			continue
+		}
+		categorys = appendCategory(categorys, name)
+	}
+	return categorys, rows.Err()
+}
+
+// list ejecuta una consulta de página sobre catalog_snapshot y carga imágenes y unidades.
+func (r *SnapshotRepo) list(ctx context.Context, querySQL string, args ...any) (*model.ProductsResult, error) {
+	rows, err := r.reader.QueryContext(ctx, querySQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error en la consulta: %w", err)
+	}
+	defer rows.Close()
+
+	result := &model.ProductsResult{Products: []model.ProductDTO{}}
+	var imageIDs []int64
+	for rows.Next() {
+		var (
+			product  model.ProductDTO
+			name     string
+			category sql.NullString
+			discount sql.NullFloat64
+			saleEnd  sql.NullTime
+			images   pq.Int64Array
+		)
+		if err := rows.Scan(&product.ID, &name, &category, &product.OriginalPrice, &product.Price, &discount, &saleEnd, &product.Stock, &images, &result.Total); err != nil {
+			log.Printf("Error to read row elemnt: %v\n", err)
+			continue
+		}
+		if product.Name, err = getValueJson(name, ""); err != nil {
+			log.Printf("Error en name: %v", err)
+		}
+		if category.Valid {
+			product.CategoryName = category.String
+			product.Category = lastCategory(category.String)
+		}
+		product.Discount = discount.Float64
+		if saleEnd.Valid {
+			product.SaleEndDate = &saleEnd.Time
+		}
+		imageIDs = append(imageIDs, images...)
+		result.Products = append(result.Products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error leyendo productos: %w", err)
+	}
+
+	if err := r.attachSnapshotImages(ctx, result.Products, imageIDs); err != nil {
+		return nil, err
+	}
+	if err := r.units.attachUnits(ctx, result.Products); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// attachSnapshotImages carga por id las imágenes referenciadas en la instantánea.
+func (r *SnapshotRepo) attachSnapshotImages(ctx context.Context, products []model.ProductDTO, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	index := make(map[uint64]int, len(products))
+	for i, p := range products {
+		index[p.ID] = i
+	}
+	rows, err := r.reader.QueryContext(ctx, "SELECT res_id, mimetype, db_datas FROM ir_attachment WHERE id = ANY($1) ORDER BY id;", pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error al obtener las imágenes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id       uint64
+			mime     string
+			db_datas []byte
+		)
+		if err := rows.Scan(&id, &mime, &db_datas); err != nil {
+			log.Printf("error al leer el valor de db_datas: %v", err)
+			continue
+		}
+		i, ok := index[id]
+		if !ok {
+			continue
+		}
+		products[i].Images = append(products[i].Images, fmt.Sprintf("data:%s;base64,", mime)+base64.StdEncoding.EncodeToString(db_datas))
+	}
+	return rows.Err()
+}
+
+// snapshotSelect produce las filas de catalog_snapshot para los productos visibles.
+// $1 es la tarifa, $2 la marca de tiempo de la carga y $3 los ids a refrescar (NULL = todos).
+func (r *SnapshotRepo) snapshotSelect() string {
+	name := r.units.schema.name("pt", "product.template")
+	return `WITH stock AS (SELECT product_id, SUM(quantity) AS qty FROM ` + stockSource(r.config) + ` quants WHERE location_id = ` + strconv.FormatInt(r.config.location(), 10) + ` GROUP BY product_id)
+	SELECT pp.id, pt.id, ` + name + `, (SELECT string_agg(value, ' ') FROM jsonb_each_text(` + name + `)), pt.categ_id, pc.name,
+		pt.list_price, ROUND(COALESCE(` + effectivePrice + `, pt.list_price), 2), item.date_end, COALESCE(st.qty, 0),
+		ARRAY(SELECT a.id FROM ir_attachment a WHERE a.res_id = pp.id AND length(a.db_datas) > 0 AND (a.mimetype = 'image/png' OR a.mimetype = 'image/jpeg') ORDER BY a.id),
+		$2::timestamptz
+	FROM product_product pp
+	INNER JOIN product_template pt ON pt.id = pp.product_tmpl_id
+	LEFT JOIN product_category pc ON pc.id = pt.categ_id
+	LEFT JOIN stock st ON st.product_id = pp.id
+	LEFT JOIN LATERAL (` + pricelistItem(r.units.schema) + `) item ON true
+	WHERE ` + r.config.Visibility.condition("pt", "pp") + ` AND ($3::int[] IS NULL OR pp.id = ANY($3))`
+}
+
+// snapshotChanges devuelve los productos modificados desde $1 (hora UTC de Odoo):
+// la variante, su plantilla, sus quants o los quants de los componentes si es un kit.
+const snapshotChanges = `SELECT pp.id FROM product_product pp INNER JOIN product_template pt ON pt.id = pp.product_tmpl_id WHERE pp.write_date > $1 OR pt.write_date > $1
+	UNION SELECT product_id FROM stock_quant WHERE write_date > $1
+	UNION SELECT kit.product_id FROM (` + phantomBoms + `) kit INNER JOIN mrp_bom_line bl ON bl.bom_id = kit.bom_id INNER JOIN stock_quant q ON q.product_id = bl.product_id WHERE q.write_date > $1`
+
+// pricelistSignature resume las reglas de la tarifa $1 y la propia tarifa. A diferencia de
+// write_date, cambia también cuando se borra una regla.
+const pricelistSignature = `SELECT COALESCE((SELECT md5(string_agg(i.id::text || ':' || i.write_date::text, ',' ORDER BY i.id))
+		FROM product_pricelist_item i WHERE i.pricelist_id = $1), '')
+	|| ':' || COALESCE((SELECT pl.active::text || pl.write_date::text FROM product_pricelist pl WHERE pl.id = $1), '')`
+
+// Refresh actualiza la instantánea. Con full se recalcula todo y se borran los productos
+// que ya no son visibles; si no, solo los productos cambiados desde la última carga.
+// Cualquier cambio en la tarifa, también borrar una regla, obliga a una carga completa.
+func (r *SnapshotRepo) Refresh(ctx context.Context, full bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var stamp, now time.Time
+	if err := r.DB.QueryRowContext(ctx, "SELECT now(), now() AT TIME ZONE 'UTC';").Scan(&stamp, &now); err != nil {
+		return fmt.Errorf("error leyendo la hora de la base de datos: %w", err)
+	}
+	if r.since.IsZero() {
+		full = true
+	}
+
+	var pricelist string
+	if err := r.DB.QueryRowContext(ctx, pricelistSignature+";", r.config.PricelistID).Scan(&pricelist); err != nil {
+		return fmt.Errorf("error revisando la tarifa: %w", err)
+	}
+	if pricelist != r.pricelist {
+		full = true
+	}
+
+	var ids pq.Int64Array
+	if !full {
+		rows, err := r.DB.QueryContext(ctx, snapshotChanges+";", r.since)
+		if err != nil {
+			return fmt.Errorf("error buscando cambios: %w", err)
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error buscando cambios: %w", err)
+		}
+		if len(ids) == 0 {
+			r.since = now
+			return nil
+		}
+	}
+
+	if err := r.write(ctx, stamp, full, ids); err != nil {
+		return err
+	}
+
+	r.since = now
+	r.pricelist = pricelist
+	r.ready.Store(true)
+	if full {
+		log.Printf("catalog snapshot fully refreshed")
+	} else {
+		log.Printf("catalog snapshot refreshed %d products", len(ids))
+	}
+	return nil
+}
+
+// write recalcula las filas de ids, o todas con full, con la marca de tiempo stamp y borra
+// las que dejaron de ser visibles.
+func (r *SnapshotRepo) write(ctx context.Context, stamp time.Time, full bool, ids pq.Int64Array) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error iniciando la transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	upsert := `INSERT INTO catalog_snapshot (product_id, product_tmpl_id, name, name_search, category_id, category_name, list_price, price, sale_end, stock, image_ids, refreshed_at) ` +
+		r.snapshotSelect() + `
+	ON CONFLICT (product_id) DO UPDATE SET product_tmpl_id = EXCLUDED.product_tmpl_id, name = EXCLUDED.name, name_search = EXCLUDED.name_search,
+		category_id = EXCLUDED.category_id, category_name = EXCLUDED.category_name, list_price = EXCLUDED.list_price, price = EXCLUDED.price,
+		sale_end = EXCLUDED.sale_end, stock = EXCLUDED.stock, image_ids = EXCLUDED.image_ids, refreshed_at = EXCLUDED.refreshed_at;`
+	var idsArg any
+	if !full {
+		idsArg = ids
+	}
+	if _, err := tx.ExecContext(ctx, upsert, r.config.PricelistID, stamp, idsArg); err != nil {
+		return fmt.Errorf("error actualizando la instantánea: %w", err)
+	}
+
+	// Lo que no se acaba de escribir dejó de ser visible.
+	if full {
+		_, err = tx.ExecContext(ctx, "DELETE FROM catalog_snapshot WHERE refreshed_at < $1;", stamp)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM catalog_snapshot WHERE refreshed_at < $1 AND product_id = ANY($2);", stamp, ids)
+	}
+	if err != nil {
+		return fmt.Errorf("error limpiando la instantánea: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error confirmando la instantánea: %w", err)
+	}
+	return nil
+}
+
+// RefreshProducts recalcula en el momento las filas de los productos dados. Sirve para los
+// cambios de stock que snapshotChanges no ve porque no tocan Odoo, como las reservas para
+// recoger en tienda. Antes de la primera carga completa no hace nada.
+func (r *SnapshotRepo) RefreshProducts(ctx context.Context, ids ...int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.ready.Load() || len(ids) == 0 {
+		return nil
+	}
+	var stamp time.Time
+	if err := r.DB.QueryRowContext(ctx, "SELECT now();").Scan(&stamp); err != nil {
+		return fmt.Errorf("error leyendo la hora de la base de datos: %w", err)
+	}
+	return r.write(ctx, stamp, false, ids)
+}
+
+// Run refresca la instantánea cada interval y hace una carga completa cada fullEvery
+// hasta que ctx termina. Los errores se registran y se reintenta en el siguiente ciclo.
+func (r *SnapshotRepo) Run(ctx context.Context, interval, fullEvery time.Duration) {
+	lastFull := time.Time{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		full := time.Since(lastFull) >= fullEvery
+		if err := r.Refresh(ctx, full); err != nil {
+			log.Printf("error refreshing catalog snapshot: %v", err)
+		} else if full {
+			lastFull = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
+)
+
+// forStore devuelve la configuración con la compañía, la ubicación y la tarifa de la
+// tienda de la petición, si hay una. Lo que la tienda deja en 0 conserva el valor global.
+func (c ProductRepoConfig) forStore(ctx context.Context) ProductRepoConfig {
+	s, ok := store.FromContext(ctx)
+	if !ok {
+		return c
+	}
+	if s.CompanyID > 0 {
+		c.Visibility.CompanyID = s.CompanyID
+	}
+	if s.LocationID > 0 {
+		c.LocationID = s.LocationID
+	}
+	if s.PricelistID > 0 {
+		c.PricelistID = s.PricelistID
+	}
+	return c
+}
+
+// location devuelve la ubicación de stock del catálogo.
+func (c ProductRepoConfig) location() int64 {
+	if c.LocationID > 0 {
+		return c.LocationID
+	}
+	return catalogLocationID
+}
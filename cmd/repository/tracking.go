@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/odoorpc"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+)
+
+// TrackingRepo sigue un pedido online (sale.order) hasta sus albaranes de salida.
+type TrackingRepo interface {
+	// ByPartner busca el pedido entre los del contacto; ErrOrderNotFound si no es suyo.
+	ByPartner(ctx context.Context, partnerID int64, ref string) (*model.OrderTracking, error)
+	// ByEmail busca el pedido por referencia y email exacto del cliente (sin comodines);
+	// ErrOrderNotFound si alguno de los dos no coincide, sin decir cuál.
+	ByEmail(ctx context.Context, ref, email string) (*model.OrderTracking, error)
+}
+
+type rpcTrackingRepo struct {
+	client *odoorpc.Client
+	config ProductRepoConfig
+	// carrier lee el transportista de los albaranes (módulo delivery / stock_delivery).
+	carrier bool
+}
+
+// NewRPCTrackingRepo construye el seguimiento sobre un cliente de Odoo. Con carrier a false
+// no se piden los campos del transportista, que solo existen con el módulo delivery.
+func NewRPCTrackingRepo(client *odoorpc.Client, config ProductRepoConfig, carrier bool) TrackingRepo {
+	return &rpcTrackingRepo{client: client, config: config, carrier: carrier}
+}
+
+type rpcTrackedOrder struct {
+	ID         int64          `json:"id"`
+	Name       odoorpc.String `json:"name"`
+	State      odoorpc.String `json:"state"`
+	DateOrder  odoorpc.Time   `json:"date_order"`
+	PickingIDs []int64        `json:"picking_ids"`
+}
+
+type rpcTrackedPicking struct {
+	ID            int64            `json:"id"`
+	Name          odoorpc.String   `json:"name"`
+	State         odoorpc.String   `json:"state"`
+	Code          odoorpc.String   `json:"picking_type_code"`
+	ScheduledDate odoorpc.Time     `json:"scheduled_date"`
+	DateDone      odoorpc.Time     `json:"date_done"`
+	Carrier       odoorpc.Many2one `json:"carrier_id"`
+	TrackingRef   odoorpc.String   `json:"carrier_tracking_ref"`
+	TrackingURL   odoorpc.String   `json:"carrier_tracking_url"`
+}
+
+func (r *rpcTrackingRepo) ByPartner(ctx context.Context, partnerID int64, ref string) (*model.OrderTracking, error) {
+	return r.track(ctx, odoorpc.Domain{odoorpc.Cond("name", "=", ref), odoorpc.Cond("partner_id", "=", partnerID)})
+}
+
+func (r *rpcTrackingRepo) ByEmail(ctx context.Context, ref, email string) (*model.OrderTracking, error) {
+	email = strings.TrimSpace(email)
+	if ref == "" || email == "" {
+		return nil, ErrOrderNotFound
+	}
+	return r.track(ctx, odoorpc.Domain{odoorpc.Cond("name", "=", ref), emailCond("partner_id.email", email)})
+}
+
+func (r *rpcTrackingRepo) track(ctx context.Context, domain odoorpc.Domain) (*model.OrderTracking, error) {
+	ctx, cancel := withTimeout(ctx, r.config.QueryTimeout)
+	defer cancel()
+	session := companyContext(r.config.forStore(ctx))
+
+	var orders []rpcTrackedOrder
+	fields := []string{"name", "state", "date_order", "picking_ids"}
+	if err := r.client.SearchRead(ctx, "sale.order", domain, odoorpc.SearchOptions{Fields: fields, Limit: 1, Context: session}, &orders); err != nil {
+		return nil, fmt.Errorf("error al buscar el pedido: %w", err)
+	}
+	if len(orders) == 0 {
+		return nil, ErrOrderNotFound
+	}
+	order := orders[0]
+
+	var pickings []rpcTrackedPicking
+	if len(order.PickingIDs) > 0 {
+		fields := []string{"name", "state", "picking_type_code", "scheduled_date", "date_done"}
+		if r.carrier {
+			fields = append(fields, "carrier_id", "carrier_tracking_ref", "carrier_tracking_url")
+		}
+		opts := odoorpc.SearchOptions{Fields: fields, Order: "id", Context: session}
+		if err := r.client.SearchRead(ctx, "stock.picking", odoorpc.Domain{odoorpc.Cond("id", "in", order.PickingIDs)}, opts, &pickings); err != nil {
+			return nil, fmt.Errorf("error al leer las entregas: %w", err)
+		}
+	}
+	return orderTracking(order, pickings), nil
+}
+
+// trackingSteps son los pasos del seguimiento en orden.
+var trackingSteps = []string{model.TrackingConfirmed, model.TrackingReady, model.TrackingShipped, model.TrackingDelivered}
+
+// orderTracking normaliza los estados de Odoo:
+//   - confirmed: el presupuesto está confirmado (state sale o done), en date_order.
+//   - ready: el albarán de salida está reservado o hecho; la fecha es la del último albarán
+//     interno (recogida, empaquetado) terminado, si la ruta tiene varios pasos.
+//   - shipped: el albarán de salida está validado, en date_done.
+//   - delivered: igual que shipped si la entrega es propia; con transportista no se sabe
+//     cuándo llega y queda sin alcanzar.
+func orderTracking(order rpcTrackedOrder, pickings []rpcTrackedPicking) *model.OrderTracking {
+	tracking := &model.OrderTracking{Reference: string(order.Name), Shipments: []model.ShipmentTrack{}}
+	confirmed := order.State == "sale" || order.State == "done"
+
+	var readyAt *time.Time
+	for _, p := range pickings {
+		if p.Code != "outgoing" && p.State == "done" && (readyAt == nil || p.DateDone.After(*readyAt)) {
+			readyAt = p.DateDone.Ptr()
+		}
+	}
+
+	for _, p := range pickings {
+		if p.Code != "outgoing" || p.State == "cancel" {
+			continue
+		}
+		ready := p.State == "assigned" || p.State == "done"
+		shipped := p.State == "done"
+		delivered := shipped && p.TrackingRef == ""
+		var shippedAt, deliveredAt *time.Time
+		if shipped {
+			shippedAt = p.DateDone.Ptr()
+		}
+		if delivered {
+			deliveredAt = shippedAt
+		}
+		shipment := model.ShipmentTrack{
+			Reference:     string(p.Name),
+			ScheduledDate: p.ScheduledDate.Ptr(),
+			Carrier:       p.Carrier.Name,
+			TrackingRef:   string(p.TrackingRef),
+			TrackingURL:   string(p.TrackingURL),
+			Timeline: []model.TrackingStep{
+				{Status: model.TrackingConfirmed, Reached: confirmed, At: reachedAt(confirmed, order.DateOrder.Ptr())},
+				{Status: model.TrackingReady, Reached: ready, At: reachedAt(ready, readyAt)},
+				{Status: model.TrackingShipped, Reached: shipped, At: shippedAt},
+				{Status: model.TrackingDelivered, Reached: delivered, At: deliveredAt},
+			},
+		}
+		shipment.Status = lastReached(shipment.Timeline)
+		tracking.Shipments = append(tracking.Shipments, shipment)
+	}
+
+	// La línea de tiempo del pedido alcanza un paso cuando lo alcanzan todas sus entregas,
+	// en la fecha de la última.
+	tracking.Timeline = make([]model.TrackingStep, len(trackingSteps))
+	for i, status := range trackingSteps {
+		step := model.TrackingStep{Status: status, Reached: confirmed}
+		if i == 0 {
+			step.At = reachedAt(confirmed, order.DateOrder.Ptr())
+		} else if len(tracking.Shipments) == 0 {
+			step.Reached = false
+		}
+		for _, shipment := range tracking.Shipments {
+			s := shipment.Timeline[i]
+			step.Reached = step.Reached && s.Reached
+			if s.At != nil && (step.At == nil || s.At.After(*step.At)) {
+				step.At = s.At
+			}
+		}
+		if !step.Reached {
+			step.At = nil
+		}
+		tracking.Timeline[i] = step
+	}
+
+	switch order.State {
+	case "draft", "sent":
+		tracking.Status = "pending"
+	case "cancel":
+		tracking.Status = "cancelled"
+	default:
+		tracking.Status = lastReached(tracking.Timeline)
+	}
+	return tracking
+}
+
+func reachedAt(reached bool, at *time.Time) *time.Time {
+	if !reached {
+		return nil
+	}
+	return at
+}
+
+// lastReached devuelve el último paso alcanzado, o "pending" si ninguno.
+func lastReached(timeline []model.TrackingStep) string {
+	status := "pending"
+	for _, step := range timeline {
+		if !step.Reached {
+			break
+		}
+		status = step.Status
+	}
+	return status
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/lib/pq"
+)
+
+// translated devuelve la expresión SQL con el texto de un campo traducible en formato jsonb
+// (ver schema.name).
+func translated(column string) string {
+	return fmt.Sprintf("COALESCE(%s->>'es_ES', %s->>'en_US')", column, column)
+}
+
+// roundToUom redondea la cantidad al múltiplo más cercano del redondeo de la unidad,
+// igual que float_round de Odoo con HALF-UP.
+func roundToUom(qty, rounding float64) float64 {
+	if rounding <= 0 {
+		return qty
+	}
+	rounded := math.Round(qty/rounding) * rounding
+	// Evita restos como 2.5000000000000004 al serializar.
+	decimals := math.Max(0, math.Ceil(-math.Log10(rounding)))
+	pow := math.Pow(10, decimals)
+	return math.Round(rounded*pow) / pow
+}
+
+// attachUnits carga la unidad de venta (uom_uom) y los empaquetados de venta
+// (product_packaging) de los productos dados y redondea su stock según la unidad.
+func (r *odooProductRepo) attachUnits(ctx context.Context, products []model.ProductDTO) error {
+	if len(products) == 0 {
+		return nil
+	}
+	index := make(map[uint64]int, len(products))
+	ids := make([]int64, len(products))
+	for i, p := range products {
+		index[p.ID] = i
+		ids[i] = int64(p.ID)
+	}
+
+	query := "SELECT pp.id, " + translated(r.schema.name("uom", "uom.uom")) + ", uom.rounding FROM product_product pp INNER JOIN product_template pt ON pt.id = pp.product_tmpl_id INNER JOIN uom_uom uom ON uom.id = pt.uom_id WHERE pp.id = ANY($1);"
+	rows, err := r.DB.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error al obtener las unidades de medida: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id  uint64
+			uom model.UomDTO
+		)
+		if err := rows.Scan(&id, &uom.Name, &uom.Rounding); err != nil {
+			log.Printf("error al leer la unidad de medida: %v", err)
+			continue
+		}
+		product := &products[index[id]]
+		product.Uom = &uom
+		product.Stock = roundToUom(product.Stock, uom.Rounding)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	query = "SELECT product_id, id, name, qty, COALESCE(barcode, '') FROM product_packaging WHERE product_id = ANY($1) AND sales ORDER BY sequence, id;"
+	rows, err = r.DB.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error al obtener los empaquetados: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id        uint64
+			packaging model.PackagingDTO
+		)
+		if err := rows.Scan(&id, &packaging.ID, &packaging.Name, &packaging.Qty, &packaging.Barcode); err != nil {
+			log.Printf("error al leer el empaquetado: %v", err)
+			continue
+		}
+		product := &products[index[id]]
+		product.Packagings = append(product.Packagings, packaging)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// VisibilityPolicy define qué banderas de publicación de Odoo debe cumplir un producto
+// para mostrarse en el catálogo público. Se aplica por igual a todas las consultas.
+type VisibilityPolicy struct {
+	// Active exige product_template.active y product_product.active (no archivados).
+	Active bool
+	// SaleOk exige product_template.sale_ok (excluye productos de uso interno).
+	SaleOk bool
+	// Published exige product_template.is_published; requiere website_sale instalado.
+	Published bool
+	// CompanyID limita a productos de la compañía dada o compartidos; 0 no filtra.
+	CompanyID int64
+}
+
+// ParseVisibilityFlags construye la política a partir de una lista separada por comas,
+// por ejemplo "active,sale_ok,published".
+func ParseVisibilityFlags(flags string, companyID int64) VisibilityPolicy {
+	policy := VisibilityPolicy{CompanyID: companyID}
+	for _, flag := range strings.Split(flags, ",") {
+		switch strings.ToLower(strings.TrimSpace(flag)) {
+		case "":
+		case "active":
+			policy.Active = true
+		case "sale_ok":
+			policy.SaleOk = true
+		case "published", "is_published", "website_published":
+			policy.Published = true
+		default:
+			log.Printf("unknown catalog visibility flag: %q", flag)
+		}
+	}
+	return policy
+}
+
+// condition devuelve la condición SQL sobre los alias de product_template y product_product dados.
+func (v VisibilityPolicy) condition(tmpl, variant string) string {
+	conds := []string{}
+	if v.Active {
+		conds = append(conds, tmpl+".active", variant+".active")
+	}
+	if v.SaleOk {
+		conds = append(conds, tmpl+".sale_ok")
+	}
+	if v.Published {
+		conds = append(conds, tmpl+".is_published")
+	}
+	if v.CompanyID > 0 {
+		conds = append(conds, fmt.Sprintf("(%s.company_id IS NULL OR %s.company_id = %d)", tmpl, tmpl, v.CompanyID))
+	}
+	if len(conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// productFilter devuelve una condición que limita la columna (un id de product_product)
+// a los productos visibles.
+func (v VisibilityPolicy) productFilter(column string) string {
+	return fmt.Sprintf("%s IN (SELECT vp.id FROM product_product vp INNER JOIN product_template vt ON vt.id = vp.product_tmpl_id WHERE %s)", column, v.condition("vt", "vp"))
+}
+
+// categoryFilter devuelve una condición que limita la categoría (alias dado) a las que
+// tienen algún producto visible en su subárbol.
+func (v VisibilityPolicy) categoryFilter(category string) string {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM product_template vt INNER JOIN product_product vp ON vp.product_tmpl_id = vt.id INNER JOIN product_category vc ON vc.id = vt.categ_id WHERE vc.parent_path LIKE %s.parent_path || '%%' AND %s)", category, v.condition("vt", "vp"))
+}
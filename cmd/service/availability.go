@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+)
+
+// AvailabilityService dice en qué tiendas físicas hay un producto, por tramos y sin
+// publicar la cantidad exacta.
+type AvailabilityService interface {
+	ByShop(ctx context.Context, productID int64) ([]model.ShopAvailability, error)
+}
+
+type availabilityService struct {
+	shops repository.ShopRepo
+	// fewLeft es la cantidad a partir de la cual (incluida) el stock deja de ser "few_left".
+	fewLeft float64
+}
+
+// NewAvailabilityService construye el servicio; con menos de fewLeft unidades libres el
+// producto se muestra como "few_left".
+func NewAvailabilityService(shops repository.ShopRepo, fewLeft float64) AvailabilityService {
+	return &availabilityService{shops: shops, fewLeft: fewLeft}
+}
+
+func (s *availabilityService) ByShop(ctx context.Context, productID int64) ([]model.ShopAvailability, error) {
+	shops, err := s.shops.GetAvailability(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range shops {
+		shops[i].Status = stockStatus(shops[i].Quantity, s.fewLeft)
+	}
+	return shops, nil
+}
+
+// stockStatus pasa una cantidad a su tramo. Las fracciones no llegan a una unidad vendible.
+func stockStatus(quantity, fewLeft float64) string {
+	switch {
+	case quantity < 1:
+		return model.StockOut
+	case quantity < fewLeft:
+		return model.StockFewLeft
+	default:
+		return model.StockAvailable
+	}
+}
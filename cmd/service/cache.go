@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/cache"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+)
+
+// Prefijos de las claves de la caché; permiten invalidar por tipo de consulta.
+const (
+	CacheKeyProduct    = "product:"
+	CacheKeyList       = "list:"
+	CacheKeyBest       = "best:"
+	CacheKeyOnSale     = "onsale:"
+	CacheKeyVariants   = "variants:"
+	CacheKeyCategories = "categories"
+	CacheKeyAttributes = "attributes"
+)
+
+// CacheConfig define el tamaño de la caché y el TTL de cada tipo de consulta.
+// Un TTL de 0 desactiva la caché para ese tipo.
+type CacheConfig struct {
+	MaxBytes    int64
+	List        time.Duration
+	Product     time.Duration
+	BestSelling time.Duration
+	OnSale      time.Duration
+	Categories  time.Duration
+	Attributes  time.Duration
+}
+
+// CachedProductService envuelve un ProductService con una caché de lectura.
+// Los valores devueltos se comparten entre peticiones y no deben modificarse.
+type CachedProductService struct {
+	next   ProductService
+	cache  *cache.Cache
+	config CacheConfig
+}
+
+// NewCachedProductService construye el decorador sobre next.
+func NewCachedProductService(next ProductService, config CacheConfig) *CachedProductService {
+	return &CachedProductService{
+		next:   next,
+		cache:  cache.New(config.MaxBytes),
+		config: config,
+	}
+}
+
+// CacheStats devuelve los aciertos, fallos y uso de memoria de la caché.
+func (s *CachedProductService) CacheStats() cache.Stats {
+	return s.cache.Stats()
+}
+
+// Invalidate elimina las entradas cuya clave empieza por alguno de los prefijos.
+func (s *CachedProductService) Invalidate(prefixes ...string) int {
+	n := 0
+	for _, prefix := range prefixes {
+		n += s.cache.DeletePrefix(prefix)
+	}
+	return n
+}
+
+// OnCatalogChange invalida las entradas afectadas por un cambio en una tabla de Odoo.
+// productID es el id de product_product cuando la tabla lo trae (stock_quant).
+// El detalle de un kit cuyo componente cambió de stock se refresca por TTL.
+func (s *CachedProductService) OnCatalogChange(table string, productID int64) {
+	switch table {
+	case "stock_quant":
+		if productID > 0 {
+			s.cache.DeletePrefix(fmt.Sprintf("%s%d@", CacheKeyProduct, productID))
+		} else {
+			s.Invalidate(CacheKeyProduct)
+		}
+		s.Invalidate(CacheKeyList, CacheKeyBest, CacheKeyOnSale)
+	case "product_template":
+		s.Invalidate(CacheKeyProduct, CacheKeyVariants, CacheKeyList, CacheKeyBest, CacheKeyOnSale, CacheKeyCategories, CacheKeyAttributes)
+	case "product_pricelist_item":
+		s.Invalidate(CacheKeyOnSale)
+	default:
+		s.Invalidate("")
+	}
+}
+
+// cached guarda el resultado de fn bajo key. La clave termina en "@" y el código de la
+// tienda de la petición, así que cada tienda tiene sus entradas y los prefijos de tipo
+// siguen invalidando todas.
+func cached[T any](ctx context.Context, s *CachedProductService, key string, ttl time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	if ttl <= 0 {
+		return fn(ctx)
+	}
+	st, _ := store.FromContext(ctx)
+	key += "@" + st.Code
+	value, err := s.cache.Do(ctx, key, ttl, func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return value.(T), nil
+}
+
+func (s *CachedProductService) GetAll(ctx context.Context, page, pageSize int) (*model.ProductsResult, error) {
+	key := fmt.Sprintf("%sall:%d:%d", CacheKeyList, page, pageSize)
+	return cached(ctx, s, key, s.config.List, func(ctx context.Context) (*model.ProductsResult, error) {
+		return s.next.GetAll(ctx, page, pageSize)
+	})
+}
+
+func (s *CachedProductService) GetByID(ctx context.Context, id int64) (*model.ProductDTO, error) {
+	key := fmt.Sprintf("%s%d", CacheKeyProduct, id)
+	return cached(ctx, s, key, s.config.Product, func(ctx context.Context) (*model.ProductDTO, error) {
+		return s.next.GetByID(ctx, id)
+	})
+}
+
+func (s *CachedProductService) GetFiltered(ctx context.Context, page, pageSize int, categID, minPrice, maxPrice *int64, category []string, name, orderValue string, attributes map[string][]string) (*model.ProductsResult, error) {
+	key := fmt.Sprintf("%sfiltered:%d:%d:%s:%s:%s:%s:%s:%s:%s", CacheKeyList, page, pageSize,
+		optional(categID), optional(minPrice), optional(maxPrice),
+		normalizeList(category), normalize(name), normalize(orderValue), normalizeAttributes(attributes))
+	return cached(ctx, s, key, s.config.List, func(ctx context.Context) (*model.ProductsResult, error) {
+		return s.next.GetFiltered(ctx, page, pageSize, categID, minPrice, maxPrice, category, name, orderValue, attributes)
+	})
+}
+
+func (s *CachedProductService) GetRelated(ctx context.Context, category, name string, page, page_size int) (*model.ProductsResult, error) {
+	key := fmt.Sprintf("%srelated:%d:%d:%s:%s", CacheKeyList, page, page_size, normalize(category), normalize(name))
+	return cached(ctx, s, key, s.config.List, func(ctx context.Context) (*model.ProductsResult, error) {
+		return s.next.GetRelated(ctx, category, name, page, page_size)
+	})
+}
+
+func (s *CachedProductService) GetBestSelling(ctx context.Context, limit int) ([]model.ProductDTO, error) {
+	key := fmt.Sprintf("%s%d", CacheKeyBest, limit)
+	return cached(ctx, s, key, s.config.BestSelling, func(ctx context.Context) ([]model.ProductDTO, error) {
+		return s.next.GetBestSelling(ctx, limit)
+	})
+}
+
+func (s *CachedProductService) GetVariants(ctx context.Context, productID int64) ([]model.ProductDTO, error) {
+	key := fmt.Sprintf("%s%d", CacheKeyVariants, productID)
+	return cached(ctx, s, key, s.config.Product, func(ctx context.Context) ([]model.ProductDTO, error) {
+		return s.next.GetVariants(ctx, productID)
+	})
+}
+
+func (s *CachedProductService) GetCategorys(ctx context.Context) ([]repository.Category, error) {
+	return cached(ctx, s, CacheKeyCategories, s.config.Categories, func(ctx context.Context) ([]repository.Category, error) {
+		return s.next.GetCategorys(ctx)
+	})
+}
+
+func (s *CachedProductService) GetOnSale(ctx context.Context, page, pageSize int, orderValue string) (*model.ProductsResult, error) {
+	key := fmt.Sprintf("%s%d:%d:%s", CacheKeyOnSale, page, pageSize, normalize(orderValue))
+	return cached(ctx, s, key, s.config.OnSale, func(ctx context.Context) (*model.ProductsResult, error) {
+		return s.next.GetOnSale(ctx, page, pageSize, orderValue)
+	})
+}
+
+func (s *CachedProductService) GetAttributes(ctx context.Context) ([]model.Attribute, error) {
+	return cached(ctx, s, CacheKeyAttributes, s.config.Attributes, func(ctx context.Context) ([]model.Attribute, error) {
+		return s.next.GetAttributes(ctx)
+	})
+}
+
+// normalize deja el texto como lo compara la consulta (sin espacios extremos ni mayúsculas).
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+func optional(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(*value)
+}
+
+// normalizeList ordena y normaliza una lista para que el orden de entrada no cambie la clave.
+func normalizeList(values []string) string {
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = normalize(value); value != "" {
+			normalized = append(normalized, value)
+		}
+	}
+	sort.Strings(normalized)
+	return fmt.Sprintf("%q", normalized)
+}
+
+func normalizeAttributes(attributes map[string][]string) string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		// Los valores de atributo se comparan exactos en SQL, así que no se pasan a minúsculas.
+		values := append([]string(nil), attributes[name]...)
+		sort.Strings(values)
+		parts = append(parts, fmt.Sprintf("%q=%q", name, values))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/mailer"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+)
+
+var (
+	// ErrInvalidCustomer indica que los datos de alta o de perfil están mal formados.
+	ErrInvalidCustomer = errors.New("datos de cliente inválidos")
+	// ErrEmailNotVerified indica que la cuenta aún no ha verificado su email, sin el cual no
+	// se muestran los pedidos del contacto.
+	ErrEmailNotVerified = errors.New("verifica tu email para ver tus pedidos")
+)
+
+// minPasswordLength es la longitud mínima de la contraseña de un cliente.
+const minPasswordLength = 8
+
+// maxOrdersPageSize limita el tamaño de página del historial de pedidos.
+const maxOrdersPageSize = 50
+
+// maxOrdersDepth limita hasta dónde se puede paginar el historial: cada página lee de cada
+// modelo todos los pedidos anteriores a ella.
+const maxOrdersDepth = 500
+
+type CustomerService interface {
+	// Signup crea un contacto nuevo en Odoo, da de alta la cuenta y envía el enlace de
+	// verificación del email.
+	Signup(ctx context.Context, signup model.CustomerSignup) (*model.CustomerProfile, error)
+	// Login acepta el email o el teléfono de la cuenta.
+	Login(ctx context.Context, login, password string) (*model.Customer, error)
+	// VerifyEmail marca el email como verificado y enlaza la cuenta al contacto de Odoo
+	// que ya tuviera ese email.
+	VerifyEmail(ctx context.Context, token string) error
+	// SendVerification vuelve a enviar el enlace de verificación si el email no está verificado.
+	SendVerification(ctx context.Context, id int64) error
+	Profile(ctx context.Context, id int64) (*model.CustomerProfile, error)
+	// UpdateProfile escribe la dirección en el contacto de Odoo y el teléfono en la cuenta.
+	UpdateProfile(ctx context.Context, id int64, address model.CustomerAddress) (*model.CustomerProfile, error)
+	// Orders devuelve el historial de pedidos del contacto de la cuenta.
+	Orders(ctx context.Context, id int64, page, pageSize int) (*model.CustomerOrders, error)
+	// Order devuelve un pedido del historial por su referencia.
+	Order(ctx context.Context, id int64, ref string) (*model.CustomerOrder, error)
+	// Tracking devuelve el seguimiento de la entrega de un pedido del cliente.
+	Tracking(ctx context.Context, id int64, ref string) (*model.OrderTracking, error)
+}
+
+// CustomerConfig configura los enlaces de verificación.
+type CustomerConfig struct {
+	// VerifyURL es la página de la tienda que recibe el token (?token=...).
+	VerifyURL string
+	// TokenTTL es la validez del enlace de verificación.
+	TokenTTL time.Duration
+}
+
+type customerService struct {
+	accounts repository.CustomerRepo
+	partners repository.PartnerRepo
+	history  repository.OrderHistoryRepo
+	tracking repository.TrackingRepo
+	mail     mailer.Mailer
+	config   CustomerConfig
+}
+
+// NewCustomerService construye el servicio de cuentas de cliente.
+func NewCustomerService(accounts repository.CustomerRepo, partners repository.PartnerRepo, history repository.OrderHistoryRepo, tracking repository.TrackingRepo, mail mailer.Mailer, config CustomerConfig) CustomerService {
+	return &customerService{accounts: accounts, partners: partners, history: history, tracking: tracking, mail: mail, config: config}
+}
+
+func (s *customerService) Signup(ctx context.Context, signup model.CustomerSignup) (*model.CustomerProfile, error) {
+	email, err := mail.ParseAddress(strings.TrimSpace(signup.Email))
+	if err != nil || email.Name != "" {
+		return nil, fmt.Errorf("%w: email inválido", ErrInvalidCustomer)
+	}
+	if len(signup.Password) < minPasswordLength {
+		return nil, fmt.Errorf("%w: la contraseña debe tener al menos %d caracteres", ErrInvalidCustomer, minPasswordLength)
+	}
+	address := trimAddress(signup.CustomerAddress)
+	if address.Name == "" {
+		return nil, fmt.Errorf("%w: falta el nombre", ErrInvalidCustomer)
+	}
+
+	// Se comprueba antes de tocar Odoo para no dejar contactos sueltos por cada alta repetida.
+	exists, err := s.accounts.Exists(ctx, email.Address, address.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, repository.ErrCustomerExists
+	}
+
+	// La cuenta empieza con un contacto propio: hasta verificar el email no se puede saber
+	// si el contacto de Odoo con ese email es de quien se registra. VerifyEmail la enlaza.
+	partnerID, err := s.partners.Create(ctx, email.Address, address)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := s.accounts.Create(ctx, email.Address, address.Phone, signup.Password, partnerID)
+	if err != nil {
+		// Otra alta con los mismos datos ganó la carrera: el contacto nuevo sobra.
+		if delErr := s.partners.Delete(ctx, partnerID); delErr != nil {
+			log.Printf("error deleting partner %d of a failed signup: %v", partnerID, delErr)
+		}
+		return nil, err
+	}
+	// La cuenta ya existe: si el correo falla el cliente puede pedir otro enlace.
+	if err := s.sendVerification(ctx, customer); err != nil {
+		log.Printf("error sending verification to customer %d: %v", customer.ID, err)
+	}
+	return s.profile(ctx, customer)
+}
+
+func (s *customerService) Login(ctx context.Context, login, password string) (*model.Customer, error) {
+	login = strings.TrimSpace(login)
+	if login == "" || password == "" {
+		return nil, repository.ErrInvalidCredentials
+	}
+	return s.accounts.Authenticate(ctx, login, password)
+}
+
+func (s *customerService) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return repository.ErrInvalidToken
+	}
+	id, err := s.accounts.VerifyEmail(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	// Con el email ya probado, la cuenta pasa al contacto más antiguo con ese email, que es
+	// el que tiene los pedidos anteriores del cliente. El email ya queda verificado aunque
+	// esto falle, así que solo se registra el error.
+	customer, err := s.accounts.Get(ctx, id)
+	if err != nil {
+		log.Printf("error linking verified customer %d: %v", id, err)
+		return nil
+	}
+	partnerID, err := s.partners.FindByEmail(ctx, customer.Email)
+	if err != nil {
+		log.Printf("error linking verified customer %d: %v", id, err)
+		return nil
+	}
+	if partnerID != 0 && partnerID != customer.PartnerID {
+		if err := s.accounts.SetPartner(ctx, id, partnerID); err != nil {
+			log.Printf("error linking verified customer %d to partner %d: %v", id, partnerID, err)
+		}
+	}
+	return nil
+}
+
+func (s *customerService) SendVerification(ctx context.Context, id int64) error {
+	customer, err := s.accounts.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if customer.EmailVerified {
+		return nil
+	}
+	return s.sendVerification(ctx, customer)
+}
+
+func (s *customerService) sendVerification(ctx context.Context, customer *model.Customer) error {
+	token, err := s.accounts.CreateToken(ctx, customer.ID, repository.TokenVerifyEmail, s.config.TokenTTL)
+	if err != nil {
+		return err
+	}
+	link := s.config.VerifyURL + "?token=" + token
+	body := "Confirma tu email para terminar de crear tu cuenta:\n\n" + link +
+		fmt.Sprintf("\n\nEl enlace caduca en %s.\n", s.config.TokenTTL)
+	return s.mail.Send(ctx, customer.Email, "Confirma tu email", body)
+}
+
+func (s *customerService) Profile(ctx context.Context, id int64) (*model.CustomerProfile, error) {
+	customer, err := s.accounts.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.profile(ctx, customer)
+}
+
+func (s *customerService) profile(ctx context.Context, customer *model.Customer) (*model.CustomerProfile, error) {
+	address, err := s.partners.Get(ctx, customer.PartnerID)
+	if err != nil {
+		return nil, err
+	}
+	// El teléfono de la cuenta es el que sirve para entrar; el del contacto puede ser otro.
+	if customer.Phone != "" {
+		address.Phone = customer.Phone
+	}
+	return &model.CustomerProfile{ID: customer.ID, Email: customer.Email, EmailVerified: customer.EmailVerified, CustomerAddress: *address}, nil
+}
+
+func (s *customerService) UpdateProfile(ctx context.Context, id int64, address model.CustomerAddress) (*model.CustomerProfile, error) {
+	address = trimAddress(address)
+	if address.Name == "" {
+		return nil, fmt.Errorf("%w: falta el nombre", ErrInvalidCustomer)
+	}
+	customer, err := s.accounts.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Primero el teléfono de la cuenta, que puede chocar con otra; si Odoo falla se restaura.
+	if address.Phone != customer.Phone {
+		if err := s.accounts.SetPhone(ctx, id, address.Phone); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.partners.Update(ctx, customer.PartnerID, address); err != nil {
+		if address.Phone != customer.Phone {
+			if restoreErr := s.accounts.SetPhone(ctx, id, customer.Phone); restoreErr != nil {
+				log.Printf("error restoring phone of customer %d: %v", id, restoreErr)
+			}
+		}
+		return nil, err
+	}
+	customer.Phone = address.Phone
+	return s.profile(ctx, customer)
+}
+
+// verifiedCustomer devuelve la cuenta si su email está verificado; el historial y el
+// seguimiento de su contacto no se muestran antes.
+func (s *customerService) verifiedCustomer(ctx context.Context, id int64) (*model.Customer, error) {
+	customer, err := s.accounts.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !customer.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+	return customer, nil
+}
+
+func (s *customerService) Orders(ctx context.Context, id int64, page, pageSize int) (*model.CustomerOrders, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > maxOrdersPageSize {
+		pageSize = maxOrdersPageSize
+	}
+	if page*pageSize > maxOrdersDepth {
+		return nil, fmt.Errorf("%w: solo se pueden ver los últimos %d pedidos", ErrInvalidCustomer, maxOrdersDepth)
+	}
+	customer, err := s.verifiedCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.history.List(ctx, customer.PartnerID, page, pageSize)
+}
+
+func (s *customerService) Order(ctx context.Context, id int64, ref string) (*model.CustomerOrder, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, repository.ErrOrderNotFound
+	}
+	customer, err := s.verifiedCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.history.Get(ctx, customer.PartnerID, ref)
+}
+
+func (s *customerService) Tracking(ctx context.Context, id int64, ref string) (*model.OrderTracking, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, repository.ErrOrderNotFound
+	}
+	customer, err := s.verifiedCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.tracking.ByPartner(ctx, customer.PartnerID, ref)
+}
+
+func trimAddress(address model.CustomerAddress) model.CustomerAddress {
+	for _, field := range []*string{&address.Name, &address.Phone, &address.Street, &address.Street2, &address.City, &address.Zip} {
+		*field = strings.TrimSpace(*field)
+	}
+	return address
+}
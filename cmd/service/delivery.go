@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+)
+
+// ErrInvalidDelivery indica que la zona o la petición de presupuesto están mal formadas.
+var ErrInvalidDelivery = errors.New("datos de entrega inválidos")
+
+type DeliveryService interface {
+	Provinces(ctx context.Context) ([]model.Province, error)
+	Municipalities(ctx context.Context, provinceID int64) ([]model.Municipality, error)
+	// Quote calcula el envío del carrito al municipio. El subtotal se calcula con los precios
+	// actuales de la tarifa, no con los de las líneas. Es solo informativo: los pedidos
+	// (POST /orders) todavía no llevan municipio ni cobran el envío.
+	Quote(ctx context.Context, req model.DeliveryQuoteRequest) (*model.DeliveryQuote, error)
+
+	Zones(ctx context.Context) ([]model.DeliveryZone, error)
+	Zone(ctx context.Context, id int64) (*model.DeliveryZone, error)
+	CreateZone(ctx context.Context, zone model.DeliveryZone) (*model.DeliveryZone, error)
+	UpdateZone(ctx context.Context, zone model.DeliveryZone) (*model.DeliveryZone, error)
+	DeleteZone(ctx context.Context, id int64) error
+}
+
+type deliveryService struct {
+	addresses repository.AddressRepo
+	zones     repository.DeliveryZoneRepo
+	prices    repository.PriceRepo
+	// location es la zona horaria de las tiendas, en la que se cuentan los días de entrega.
+	location *time.Location
+}
+
+// NewDeliveryService construye el servicio a partir del catálogo de direcciones, las zonas
+// y los precios del catálogo. location es la zona horaria de las tiendas.
+func NewDeliveryService(addresses repository.AddressRepo, zones repository.DeliveryZoneRepo, prices repository.PriceRepo, location *time.Location) DeliveryService {
+	return &deliveryService{addresses: addresses, zones: zones, prices: prices, location: location}
+}
+
+func (s *deliveryService) Provinces(ctx context.Context) ([]model.Province, error) {
+	return s.addresses.GetProvinces(ctx)
+}
+
+func (s *deliveryService) Municipalities(ctx context.Context, provinceID int64) ([]model.Municipality, error) {
+	return s.addresses.GetMunicipalities(ctx, provinceID)
+}
+
+func (s *deliveryService) Quote(ctx context.Context, req model.DeliveryQuoteRequest) (*model.DeliveryQuote, error) {
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: no hay productos", ErrInvalidDelivery)
+	}
+	if len(req.Lines) > maxOrderLines {
+		return nil, fmt.Errorf("%w: el carrito tiene más de %d líneas", ErrInvalidDelivery, maxOrderLines)
+	}
+	ids := make([]int64, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		if line.ProductID <= 0 || line.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: producto o cantidad inválidos en la línea del producto %d", ErrInvalidDelivery, line.ProductID)
+		}
+		ids = append(ids, line.ProductID)
+	}
+	prices, err := s.prices.Prices(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	quote := &model.DeliveryQuote{MunicipalityID: req.MunicipalityID}
+	for _, line := range req.Lines {
+		price, ok := prices[line.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: el producto %d no está disponible", ErrInvalidDelivery, line.ProductID)
+		}
+		quote.Subtotal += line.Quantity * price
+	}
+	quote.Subtotal = math.Round(quote.Subtotal*100) / 100
+
+	if _, err := s.addresses.GetMunicipality(ctx, req.MunicipalityID); err != nil {
+		if errors.Is(err, repository.ErrMunicipalityNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDelivery, err)
+		}
+		return nil, err
+	}
+	zone, err := s.zones.ForMunicipality(ctx, req.MunicipalityID)
+	if err != nil {
+		return nil, err
+	}
+	quote.Total = quote.Subtotal
+	if zone == nil {
+		return quote, nil
+	}
+
+	quote.Available = true
+	quote.Zone = zone.Name
+	quote.LeadTimeDays = zone.LeadTimeDays
+	if zone.FreeFrom == nil || quote.Subtotal < *zone.FreeFrom {
+		quote.Fee = zone.Fee
+	}
+	quote.Total = math.Round((quote.Subtotal+quote.Fee)*100) / 100
+	// Truncate cortaría el día en UTC; el día de entrega es el de la hora local de la tienda.
+	now := time.Now().In(s.location)
+	estimated := time.Date(now.Year(), now.Month(), now.Day()+zone.LeadTimeDays, 0, 0, 0, 0, s.location)
+	quote.EstimatedDate = &estimated
+	return quote, nil
+}
+
+func (s *deliveryService) Zones(ctx context.Context) ([]model.DeliveryZone, error) {
+	return s.zones.List(ctx)
+}
+
+func (s *deliveryService) Zone(ctx context.Context, id int64) (*model.DeliveryZone, error) {
+	return s.zones.Get(ctx, id)
+}
+
+func (s *deliveryService) CreateZone(ctx context.Context, zone model.DeliveryZone) (*model.DeliveryZone, error) {
+	if err := normalizeZone(&zone); err != nil {
+		return nil, err
+	}
+	return s.zones.Create(ctx, zone)
+}
+
+func (s *deliveryService) UpdateZone(ctx context.Context, zone model.DeliveryZone) (*model.DeliveryZone, error) {
+	if err := normalizeZone(&zone); err != nil {
+		return nil, err
+	}
+	return s.zones.Update(ctx, zone)
+}
+
+func (s *deliveryService) DeleteZone(ctx context.Context, id int64) error {
+	return s.zones.Delete(ctx, id)
+}
+
+// normalizeZone valida la zona y quita los municipios repetidos.
+func normalizeZone(zone *model.DeliveryZone) error {
+	zone.Name = strings.TrimSpace(zone.Name)
+	switch {
+	case zone.Name == "":
+		return fmt.Errorf("%w: falta el nombre de la zona", ErrInvalidDelivery)
+	case zone.Fee < 0 || (zone.FreeFrom != nil && *zone.FreeFrom < 0):
+		return fmt.Errorf("%w: los importes no pueden ser negativos", ErrInvalidDelivery)
+	case zone.LeadTimeDays < 0:
+		return fmt.Errorf("%w: el plazo de entrega no puede ser negativo", ErrInvalidDelivery)
+	}
+	slices.Sort(zone.Municipalities)
+	zone.Municipalities = slices.Compact(zone.Municipalities)
+	return nil
+}
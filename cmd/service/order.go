@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+)
+
+// ErrInvalidOrder indica que la petición de pedido está mal formada.
+var ErrInvalidOrder = errors.New("pedido inválido")
+
+// maxOrderLines limita el tamaño del carrito aceptado.
+const maxOrderLines = 100
+
+type OrderService interface {
+	// PlaceOrder crea el pedido una sola vez por clave de idempotencia. created es false
+	// cuando la clave ya tenía un pedido de la misma petición y se devuelve ese; si era de
+	// otra petición devuelve repository.ErrIdempotencyMismatch.
+	PlaceOrder(ctx context.Context, key string, order model.OrderRequest) (result *model.OrderResult, created bool, err error)
+}
+
+type orderService struct {
+	repo repository.OrderRepo
+
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock serializa las peticiones concurrentes con la misma clave.
+type keyLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// NewOrderService construye el servicio a partir de un OrderRepo.
+func NewOrderService(r repository.OrderRepo) OrderService {
+	return &orderService{repo: r, locks: make(map[string]*keyLock)}
+}
+
+func (s *orderService) PlaceOrder(ctx context.Context, key string, order model.OrderRequest) (*model.OrderResult, bool, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || len(key) > 64 {
+		return nil, false, fmt.Errorf("%w: falta la cabecera Idempotency-Key o es demasiado larga", ErrInvalidOrder)
+	}
+	if err := validateOrder(order); err != nil {
+		return nil, false, err
+	}
+
+	// Un reintento mientras el primero sigue en curso espera y encuentra el pedido creado.
+	st, _ := store.FromContext(ctx)
+	unlock := s.lock(st.Code + ":" + key)
+	defer unlock()
+
+	existing, err := s.repo.FindByKey(ctx, key, order)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+	result, err := s.repo.Create(ctx, key, order)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
+}
+
+func (s *orderService) lock(key string) func() {
+	s.mu.Lock()
+	l, ok := s.locks[key]
+	if !ok {
+		l = &keyLock{}
+		s.locks[key] = l
+	}
+	l.waiters++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		if l.waiters--; l.waiters == 0 {
+			delete(s.locks, key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// validateOrder comprueba el carrito y los datos del cliente antes de consultar Odoo.
+func validateOrder(order model.OrderRequest) error {
+	if len(order.Lines) == 0 {
+		return fmt.Errorf("%w: el carrito está vacío", ErrInvalidOrder)
+	}
+	if len(order.Lines) > maxOrderLines {
+		return fmt.Errorf("%w: el carrito tiene más de %d líneas", ErrInvalidOrder, maxOrderLines)
+	}
+	for _, line := range order.Lines {
+		if line.ProductID <= 0 || line.Quantity <= 0 {
+			return fmt.Errorf("%w: producto o cantidad inválidos en la línea del producto %d", ErrInvalidOrder, line.ProductID)
+		}
+	}
+	if strings.TrimSpace(order.Customer.Name) == "" {
+		return fmt.Errorf("%w: falta el nombre del cliente", ErrInvalidOrder)
+	}
+	if _, err := mail.ParseAddress(order.Customer.Email); err != nil {
+		return fmt.Errorf("%w: email inválido", ErrInvalidOrder)
+	}
+	return nil
+}
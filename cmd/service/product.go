@@ -1,20 +1,24 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/store"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
 	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
 )
 
 type ProductService interface {
-	GetAll(page, pageSize int) (*model.ProductsResult, error)
-	GetByID(id int64) (*model.ProductDTO, error)
-	GetFiltered(page, pageSize int, categID, minPrice, maxPrice *int64, category []string, name, orderValue string) (*model.ProductsResult, error)
-	GetRelated(category, name string, page, page_size int) (*model.ProductsResult, error)
-	GetBestSelling(limit int) ([]model.ProductDTO, error)
-	GetVariants(productID int64) ([]model.ProductDTO, error)
-	GetCategorys() ([]repository.Category, error)
+	GetAll(ctx context.Context, page, pageSize int) (*model.ProductsResult, error)
+	GetByID(ctx context.Context, id int64) (*model.ProductDTO, error)
+	GetFiltered(ctx context.Context, page, pageSize int, categID, minPrice, maxPrice *int64, category []string, name, orderValue string, attributes map[string][]string) (*model.ProductsResult, error)
+	GetRelated(ctx context.Context, category, name string, page, page_size int) (*model.ProductsResult, error)
+	GetBestSelling(ctx context.Context, limit int) ([]model.ProductDTO, error)
+	GetVariants(ctx context.Context, productID int64) ([]model.ProductDTO, error)
+	GetCategorys(ctx context.Context) ([]repository.Category, error)
+	GetOnSale(ctx context.Context, page, pageSize int, orderValue string) (*model.ProductsResult, error)
+	GetAttributes(ctx context.Context) ([]model.Attribute, error)
 }
 
 type productService struct {
@@ -27,33 +31,34 @@ func NewProductService(r repository.ProductRepo) ProductService {
 }
 
 // GetAll aplica paginación a partir de page y pageSize.
-func (s *productService) GetAll(page, pageSize int) (*model.ProductsResult, error) {
+func (s *productService) GetAll(ctx context.Context, page, pageSize int) (*model.ProductsResult, error) {
 	if page < 1 {
 		return nil, fmt.Errorf("page debe ser >= 1")
 	}
 	offset := (page - 1) * pageSize
-	return s.repo.GetAll(offset, pageSize)
+	return resultWithCurrency(ctx)(s.repo.GetAll(ctx, offset, pageSize))
 }
-func (s *productService) GetByID(id int64) (*model.ProductDTO, error) {
+func (s *productService) GetByID(ctx context.Context, id int64) (*model.ProductDTO, error) {
 
-	product, err := s.repo.GetByID(id)
+	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener producto: %w", err)
 	}
+	product.Currency = currency(ctx)
 	return product, nil
 }
 
 // GetFiltered delega el filtrado con paginación al repo.
-func (s *productService) GetFiltered(page, pageSize int, categID, minPrice, maxPrice *int64, category []string, name, orderValue string) (*model.ProductsResult, error) {
+func (s *productService) GetFiltered(ctx context.Context, page, pageSize int, categID, minPrice, maxPrice *int64, category []string, name, orderValue string, attributes map[string][]string) (*model.ProductsResult, error) {
 	if page < 1 {
 		return nil, fmt.Errorf("page debe ser >= 1")
 	}
 	offset := (page - 1) * pageSize
-	return s.repo.GetFiltered(offset, pageSize, categID, minPrice, maxPrice, category, name, orderValue)
+	return resultWithCurrency(ctx)(s.repo.GetFiltered(ctx, offset, pageSize, categID, minPrice, maxPrice, category, name, orderValue, attributes))
 }
 
 // GetRelated toma el límite y delega a repo.
-func (s *productService) GetRelated(category, name string, page, page_size int) (*model.ProductsResult, error) {
+func (s *productService) GetRelated(ctx context.Context, category, name string, page, page_size int) (*model.ProductsResult, error) {
 
 	if page_size < 1 {
 		page_size = 5
@@ -63,24 +68,73 @@ func (s *productService) GetRelated(category, name string, page, page_size int)
 	}
 	offset := (page - 1) * page_size
 
-	return s.repo.GetRelated(category, name, &offset, &page_size)
+	return resultWithCurrency(ctx)(s.repo.GetRelated(ctx, category, name, &offset, &page_size))
 }
 
 // GetBestSelling delega a repo (limit por defecto si se pasa 0).
-func (s *productService) GetBestSelling(limit int) ([]model.ProductDTO, error) {
+func (s *productService) GetBestSelling(ctx context.Context, limit int) ([]model.ProductDTO, error) {
 	if limit < 1 {
 		limit = 6
 	}
-	return s.repo.GetBestSelling(limit)
+	return listWithCurrency(ctx)(s.repo.GetBestSelling(ctx, limit))
 }
-func (s *productService) GetCategorys() ([]repository.Category, error) {
-	return s.repo.GetCategorys()
+func (s *productService) GetCategorys(ctx context.Context) ([]repository.Category, error) {
+	return s.repo.GetCategorys(ctx)
 }
 
 // GetVariants delega a repo.
-func (s *productService) GetVariants(productID int64) ([]model.ProductDTO, error) {
+func (s *productService) GetVariants(ctx context.Context, productID int64) ([]model.ProductDTO, error) {
 	if productID <= 0 {
 		return nil, fmt.Errorf("productID inválido")
 	}
-	return s.repo.GetVariants(productID)
+	return listWithCurrency(ctx)(s.repo.GetVariants(ctx, productID))
+}
+
+// GetOnSale aplica paginación y delega a repo los productos con descuento.
+func (s *productService) GetOnSale(ctx context.Context, page, pageSize int, orderValue string) (*model.ProductsResult, error) {
+	if page < 1 {
+		return nil, fmt.Errorf("page debe ser >= 1")
+	}
+	offset := (page - 1) * pageSize
+	return resultWithCurrency(ctx)(s.repo.GetOnSale(ctx, offset, pageSize, orderValue))
+}
+
+// GetAttributes delega a repo.
+func (s *productService) GetAttributes(ctx context.Context) ([]model.Attribute, error) {
+	return s.repo.GetAttributes(ctx)
+}
+
+// currency devuelve la moneda de la tienda de la petición, o "" si no hay tienda.
+func currency(ctx context.Context) string {
+	st, _ := store.FromContext(ctx)
+	return st.Currency
+}
+
+// setCurrency marca los precios con la moneda de la tienda de la petición.
+func setCurrency(ctx context.Context, products []model.ProductDTO) {
+	if c := currency(ctx); c != "" {
+		for i := range products {
+			products[i].Currency = c
+		}
+	}
+}
+
+// resultWithCurrency aplica setCurrency al resultado de una llamada al repo.
+func resultWithCurrency(ctx context.Context) func(*model.ProductsResult, error) (*model.ProductsResult, error) {
+	return func(result *model.ProductsResult, err error) (*model.ProductsResult, error) {
+		if err == nil && result != nil {
+			setCurrency(ctx, result.Products)
+		}
+		return result, err
+	}
+}
+
+// listWithCurrency aplica setCurrency a la lista devuelta por el repo.
+func listWithCurrency(ctx context.Context) func([]model.ProductDTO, error) ([]model.ProductDTO, error) {
+	return func(products []model.ProductDTO, err error) ([]model.ProductDTO, error) {
+		if err == nil {
+			setCurrency(ctx, products)
+		}
+		return products, err
+	}
 }
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/internal/mailer"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+)
+
+// ErrInvalidReservation indica que la petición de reserva está mal formada.
+var ErrInvalidReservation = errors.New("reserva inválida")
+
+// Límites de una reserva: como no hace falta pagar ni tener cuenta, impiden que una sola
+// petición retenga todo el stock de una tienda durante el TTL.
+const (
+	maxReservationLines        = 20
+	maxReservationLineQuantity = 10
+	maxReservationQuantity     = 30
+)
+
+type ReservationService interface {
+	// Shops devuelve las tiendas donde se puede recoger.
+	Shops(ctx context.Context) ([]model.Shop, error)
+	// Reserve retiene el stock en la tienda elegida y envía al cliente el código de recogida.
+	Reserve(ctx context.Context, req model.ReservationRequest) (*model.Reservation, error)
+	Get(ctx context.Context, code string) (*model.Reservation, error)
+	// Cancel libera la reserva a petición del cliente.
+	Cancel(ctx context.Context, code string) (*model.Reservation, error)
+	// Collect marca la reserva como pagada y recogida en la tienda.
+	Collect(ctx context.Context, code string) (*model.Reservation, error)
+	// Run libera las reservas caducadas cada interval hasta que ctx se cancele.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type reservationService struct {
+	repo  repository.ReservationRepo
+	shops repository.ShopRepo
+	mail  mailer.Mailer
+	ttl   time.Duration
+	// onStockChange avisa de que cambió el stock libre de un producto (p. ej. a la caché).
+	onStockChange func(productID int64)
+}
+
+// NewReservationService construye el servicio; ttl es cuánto se guarda una reserva y
+// onStockChange, si no es nil, se llama por cada producto cuya reserva se crea o se libera.
+func NewReservationService(r repository.ReservationRepo, shops repository.ShopRepo, mail mailer.Mailer, ttl time.Duration, onStockChange func(productID int64)) ReservationService {
+	if onStockChange == nil {
+		onStockChange = func(int64) {}
+	}
+	return &reservationService{repo: r, shops: shops, mail: mail, ttl: ttl, onStockChange: onStockChange}
+}
+
+func (s *reservationService) Shops(ctx context.Context) ([]model.Shop, error) {
+	return s.shops.GetShops(ctx)
+}
+
+func (s *reservationService) Reserve(ctx context.Context, req model.ReservationRequest) (*model.Reservation, error) {
+	if err := validateReservation(req); err != nil {
+		return nil, err
+	}
+	shop, err := s.shops.GetShop(ctx, req.ShopID)
+	if errors.Is(err, repository.ErrShopNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReservation, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.repo.Create(ctx, *shop, req, s.ttl)
+	if err != nil {
+		return nil, err
+	}
+	s.stockChanged(reservation)
+	body := fmt.Sprintf("Hola %s:\n\nTe guardamos tu reserva en %s hasta el %s.\n\nTu código de recogida es %s. Enséñalo en caja para pagar y llevártela.\n",
+		reservation.CustomerName, shop.Name, reservation.ExpiresAt.Format("02/01/2006 15:04"), reservation.Code)
+	if err := s.mail.Send(ctx, reservation.Email, "Tu código de recogida: "+reservation.Code, body); err != nil {
+		// La reserva ya está hecha y la respuesta también lleva el código.
+		log.Printf("error sending pickup code %s: %v", reservation.Code, err)
+	}
+	return reservation, nil
+}
+
+func (s *reservationService) Get(ctx context.Context, code string) (*model.Reservation, error) {
+	return s.repo.Get(ctx, code)
+}
+
+func (s *reservationService) Cancel(ctx context.Context, code string) (*model.Reservation, error) {
+	reservation, err := s.repo.Close(ctx, code, repository.ReservationCancelled)
+	if err != nil {
+		return nil, err
+	}
+	s.stockChanged(reservation)
+	return reservation, nil
+}
+
+func (s *reservationService) Collect(ctx context.Context, code string) (*model.Reservation, error) {
+	// Al recogerla, el punto de venta descuenta el stock de Odoo; la reserva deja de restar.
+	reservation, err := s.repo.Close(ctx, code, repository.ReservationCollected)
+	if err != nil {
+		return nil, err
+	}
+	s.stockChanged(reservation)
+	return reservation, nil
+}
+
+func (s *reservationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		expired, err := s.repo.Expire(ctx)
+		if err != nil {
+			log.Printf("error releasing expired reservations: %v", err)
+		}
+		for _, reservation := range expired {
+			s.stockChanged(&reservation)
+			body := fmt.Sprintf("Hola %s:\n\nTu reserva %s en %s ha caducado y los productos vuelven a estar a la venta.\n",
+				reservation.CustomerName, reservation.Code, reservation.Shop.Name)
+			if err := s.mail.Send(ctx, reservation.Email, "Tu reserva ha caducado", body); err != nil {
+				log.Printf("error notifying expired reservation %s: %v", reservation.Code, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *reservationService) stockChanged(reservation *model.Reservation) {
+	for _, line := range reservation.Lines {
+		s.onStockChange(line.ProductID)
+	}
+}
+
+// validateReservation comprueba las líneas y los datos de contacto antes de tocar el stock.
+func validateReservation(req model.ReservationRequest) error {
+	if req.ShopID <= 0 {
+		return fmt.Errorf("%w: falta la tienda", ErrInvalidReservation)
+	}
+	if len(req.Lines) == 0 {
+		return fmt.Errorf("%w: no hay productos", ErrInvalidReservation)
+	}
+	if len(req.Lines) > maxReservationLines {
+		return fmt.Errorf("%w: la reserva tiene más de %d líneas", ErrInvalidReservation, maxReservationLines)
+	}
+	// Las líneas repetidas de un producto cuentan juntas para el límite por producto.
+	quantities := make(map[int64]float64, len(req.Lines))
+	total := 0.0
+	for _, line := range req.Lines {
+		if line.ProductID <= 0 || line.Quantity <= 0 {
+			return fmt.Errorf("%w: producto o cantidad inválidos en la línea del producto %d", ErrInvalidReservation, line.ProductID)
+		}
+		quantities[line.ProductID] += line.Quantity
+		if quantities[line.ProductID] > maxReservationLineQuantity {
+			return fmt.Errorf("%w: no se pueden reservar más de %d unidades del producto %d", ErrInvalidReservation, maxReservationLineQuantity, line.ProductID)
+		}
+		total += line.Quantity
+	}
+	if total > maxReservationQuantity {
+		return fmt.Errorf("%w: no se pueden reservar más de %d unidades en total", ErrInvalidReservation, maxReservationQuantity)
+	}
+	if strings.TrimSpace(req.Customer.Name) == "" {
+		return fmt.Errorf("%w: falta el nombre del cliente", ErrInvalidReservation)
+	}
+	if _, err := mail.ParseAddress(req.Customer.Email); err != nil {
+		return fmt.Errorf("%w: email inválido", ErrInvalidReservation)
+	}
+	return nil
+}
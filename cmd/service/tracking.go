@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/model"
+	"github.com/fidellopezm03/marcos-backend-postgresql/cmd/repository"
+)
+
+// TrackingService es el seguimiento de pedidos sin cuenta: por referencia y email.
+type TrackingService interface {
+	Lookup(ctx context.Context, ref, email string) (*model.OrderTracking, error)
+}
+
+type trackingService struct {
+	repo repository.TrackingRepo
+}
+
+// NewTrackingService construye el servicio a partir de un TrackingRepo.
+func NewTrackingService(r repository.TrackingRepo) TrackingService {
+	return &trackingService{repo: r}
+}
+
+func (s *trackingService) Lookup(ctx context.Context, ref, email string) (*model.OrderTracking, error) {
+	return s.repo.ByEmail(ctx, strings.TrimSpace(ref), email)
+}
@@ -3,6 +3,7 @@ module github.com/fidellopezm03/marcos-backend-postgresql
 go 1.24.3
 
 require (
+	github.com/andybalholm/brotli v1.2.6
 	github.com/go-chi/chi/v5 v5.2.1
 	github.com/go-chi/cors v1.2.1
 	github.com/go-chi/jwtauth v1.2.0